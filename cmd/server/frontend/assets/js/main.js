@@ -1,5 +1,8 @@
 // Common functions for board creation and display
 
+// Plant types with a picture, the others are drawn with their first letter
+const plantImages = ['nuclear', 'gas', 'wind', 'solar'];
+
 // Function to create a grid for a board
 function createGrid(containerId, size = 10) {
     const container = $(`#${containerId}`);
@@ -99,7 +102,9 @@ function updateBoard(boardId, playerName, boardData, showPlants = false) {
                 const isHit = boardData.hits && plantCoords.some(pc => boardData.hits.includes(pc));
                 newState.cells[coord] = {
                     classes: ['cell', 'plant-container', 'normal', isHit ? 'plant-damaged' : 'plant-working'],
-                    html: `<img src="assets/img/${plantType}.png" alt="${plantType}">`
+                    html: plantImages.includes(plantType)
+                        ? `<img src="assets/img/${plantType}.png" alt="${plantType}">`
+                        : `<span class="plant-symbol" title="${plant.type}">${plant.type.charAt(0)}</span>`
                 };
             });
         });
@@ -38,6 +38,22 @@ $(document).ready(function() {
     let gameData = null;
     let opponentName = null;
     let selectedPlantType = null;
+    let plantRotation = 0; // Clockwise rotation of shaped plants, in degrees
+    let plantCatalog = null; // Plants of the game, set from the game data
+    
+    // Plants of games created without a catalog
+    const classicCatalog = {
+        name: 'classic',
+        plants: [
+            { type: 'NUCLEAR', capacity: 1000, width: 3, height: 3 },
+            { type: 'GAS', capacity: 300, width: 2, height: 2 },
+            { type: 'WIND', capacity: 100, width: 2, height: 1 },
+            { type: 'SOLAR', capacity: 25, width: 1, height: 1 }
+        ]
+    };
+    
+    // Plant types with a picture, the others are drawn with their symbol
+    const plantImages = ['NUCLEAR', 'GAS', 'WIND', 'SOLAR'];
     let boardSize = 10; // Default size
     let requiredCapacity = 0;
     let currentCapacity = 0;
@@ -132,7 +148,7 @@ $(document).ready(function() {
             for (const [coord, plantType] of Object.entries(plantMap)) {
                 const cell = $(`#player-board .cell[data-coord="${coord}"]`);
                 if (cell.length) {
-                    cell.html(`<img src="assets/img/${plantType}.png" alt="${plantType}">`);
+                    cell.html(plantIcon(plantType));
                     cell.addClass('plant-container normal');
                 }
             }
@@ -206,11 +222,25 @@ $(document).ready(function() {
             success: function(data) {
                 gameData = data;
                 
+                // Show the plants of the game catalog once
+                if (!plantCatalog) {
+                    plantCatalog = data.catalog || classicCatalog;
+                    renderPlantSelector();
+                }
+                
                 // Update game status information
                 $('#game-status').text(`Status: ${data.status}`);
                 $('#game-turn').text(`Turn: ${data.turn || 'N/A'}`);
                 $('#game-winner').text(`Winner: ${data.winner || 'None'}`);
                 
+                // Show the weather of the round and the forecast of the next one
+                if (data.weather && data.conditions) {
+                    const forecast = data.forecast ? ` - Next round: sun ${data.forecast.sun}%, wind ${data.forecast.wind}%` : '';
+                    $('#game-weather').text(`Round ${data.round} weather: sun ${data.conditions.sun}%, wind ${data.conditions.wind}%${forecast}`).show();
+                } else {
+                    $('#game-weather').hide();
+                }
+                
                 // Get player names
                 const playerNames = Object.keys(data.players);
                 
@@ -222,13 +252,16 @@ $(document).ready(function() {
                     const playerInfo = data.players[playerName];
                     const readyStatus = playerInfo.ready ? 'Ready' : 'Not Ready';
                     const isCurrentPlayer = playerName === window.playerName;
-                    const playerLabel = isCurrentPlayer ? `${playerName} (You)` : playerName;
+                    let playerLabel = isCurrentPlayer ? `${playerName} (You)` : playerName;
+                    if (playerInfo.team) {
+                        playerLabel += ` [Team ${playerInfo.team}]`;
+                    }
                     playersList.append(`<li>${playerLabel} - ${readyStatus} (Capacity: ${playerInfo.capacity}/${playerInfo.total_capacity})</li>`);
                 });
                 
                 // Find opponents if there are at least 2 players
                 if (playerNames.length >= 2) {
-                    const opponents = playerNames.filter(name => name !== playerName);
+                    const opponents = playerNames.filter(name => isOpponent(data.players, name));
                     
                     // Update player info
                     const playerInfo = data.players[playerName];
@@ -347,7 +380,7 @@ $(document).ready(function() {
             
             // Make sure opponent boards are visible
             // Note: We don't need to fetch opponent boards here as they're already fetched in updateGameData
-            const opponents = Object.keys(gameData.players).filter(name => name !== playerName);
+            const opponents = Object.keys(gameData.players).filter(name => isOpponent(gameData.players, name));
             if (opponents.length > 0) {
                 // Just make sure the container is visible
                 $('#opponents-container').show();
@@ -388,8 +421,9 @@ $(document).ready(function() {
         const token = getPlayerToken();
 
         $.ajax({
-            url: `/api/games/${gameId}/players/${playerName}/board?token=${token}`,
+            url: `/api/games/${gameId}/players/${playerName}/board`,
             type: 'GET',
+            headers: { 'Authorization': `Bearer ${token}` },
             success: function(data) {
                 playerBoard = data;
                 // Ensure plants is initialized
@@ -526,51 +560,23 @@ $(document).ready(function() {
             playerBoard.plants = [];
         }
         
-        // Get plant size
-        let width, height;
-        switch (type) {
-            case 'NUCLEAR':
-                width = height = 3;
-                break;
-            case 'GAS':
-                width = height = 2;
-                break;
-            case 'WIND':
-                if ($('input[name="orientation"]:checked').val() === 'horizontal') {
-                    width = 2;
-                    height = 1;
-                } else {
-                    width = 1;
-                    height = 2;
-                }
-                break;
-            case 'SOLAR':
-                width = height = 1;
-                break;
-            default:
-                return;
-        }
+        // Get the cells of the plant
+        const spec = plantSpec(type);
+        if (!spec) return;
+        const cells = plantCells(spec);
         
         // Parse coordinate
         const row = coord.charCodeAt(0) - 65; // A=0, B=1, etc.
         const col = parseInt(coord.substring(1)) - 1; // 1-indexed to 0-indexed
         
         // Check if plant fits on the board
-        if (row + height > boardSize || col + width > boardSize) {
+        if (cells.some(([r, c]) => row + r >= boardSize || col + c >= boardSize)) {
             showError('Plant does not fit on the board!');
             return false;
         }
         
         // Generate all coordinates for the plant
-        const coordinates = [];
-        for (let r = 0; r < height; r++) {
-            for (let c = 0; c < width; c++) {
-                const newRow = row + r;
-                const newCol = col + c;
-                const newCoord = `${String.fromCharCode(65 + newRow)}${newCol + 1}`;
-                coordinates.push(newCoord);
-            }
-        }
+        const coordinates = cells.map(([r, c]) => `${String.fromCharCode(65 + row + r)}${col + c + 1}`);
         
         // Check if any of the coordinates are already occupied
         if (playerBoard.plants && playerBoard.plants.length > 0) {
@@ -591,23 +597,7 @@ $(document).ready(function() {
         });
         
         // Update capacity
-        let plantCapacity;
-        switch (type) {
-            case 'NUCLEAR':
-                plantCapacity = 1000;
-                break;
-            case 'GAS':
-                plantCapacity = 300;
-                break;
-            case 'WIND':
-                plantCapacity = 100;
-                break;
-            case 'SOLAR':
-                plantCapacity = 25;
-                break;
-            default:
-                plantCapacity = 0;
-        }
+        const plantCapacity = spec.capacity;
         
         // Update capacity values
         currentCapacity += plantCapacity;
@@ -620,7 +610,7 @@ $(document).ready(function() {
         for (const coord of coordinates) {
             const cell = $(`#player-board .cell[data-coord="${coord}"]`);
             if (cell.length) {
-                cell.html(`<img src="assets/img/${type.toLowerCase()}.png" alt="${type}">`);
+                cell.html(plantIcon(type));
                 cell.addClass('plant-container normal plant-working');
             }
         }
@@ -645,8 +635,9 @@ function saveBoard(callback) {
         const token = getPlayerToken();
         
         $.ajax({
-            url: `/api/games/${gameId}/players/${playerName}/board?token=${token}`,
+            url: `/api/games/${gameId}/players/${playerName}/board`,
             type: 'POST',
+            headers: { 'Authorization': `Bearer ${token}` },
             contentType: 'application/json',
             data: JSON.stringify({
                 plants: playerBoard.plants
@@ -678,8 +669,9 @@ function setPlayerReady() {
         const token = getPlayerToken();
         
         $.ajax({
-            url: `/api/games/${gameId}/players/${playerName}/ready?token=${token}`,
+            url: `/api/games/${gameId}/players/${playerName}/ready`,
             type: 'POST',
+            headers: { 'Authorization': `Bearer ${token}` },
             success: function(data) {
                 console.log('Player marked as ready:', data);
                 isPlayerReady = true;
@@ -699,6 +691,15 @@ function setPlayerReady() {
     }
 }
     
+    // Function to check if a player is an opponent, teammates are not
+function isOpponent(players, name) {
+    if (name === playerName) {
+        return false;
+    }
+    const team = players[playerName] && players[playerName].team;
+    return !team || players[name].team !== team;
+}
+
     // Function to strike opponent's board
 function strikeOpponent(coord, targetOpponent) {
     if (!gameData || gameData.status !== 'IN_PROGRESS' || gameData.turn !== playerName) {
@@ -722,12 +723,15 @@ function strikeOpponent(coord, targetOpponent) {
         const token = getPlayerToken();
         
         $.ajax({
-            url: `/api/games/${gameId}/players/${playerName}/strike?token=${token}&target=${target}&y=${y}&x=${x}`,
+            url: `/api/games/${gameId}/players/${playerName}/strike?target=${target}&y=${y}&x=${x}`,
             type: 'POST',
+            headers: { 'Authorization': `Bearer ${token}` },
             success: function(data) {
                 console.log('Strike result:', data);
                 
-                if (data.result === 'HIT') {
+                if (data.result === 'DESTROYED') {
+                    showSuccess(`DESTROYED! You took down ${target}'s power plant!`);
+                } else if (data.result === 'HIT') {
                     showSuccess(`HIT! You struck ${target}'s power plant!`);
                 } else {
                     showSuccess(`MISS! You didn't hit anything on ${target}'s board.`);
@@ -775,17 +779,93 @@ function strikeOpponent(coord, targetOpponent) {
         // Don't auto-hide messages
     }
     
+    // Function to get the cells of a plant as the player lays it, as
+    // [row, column] offsets from the top left corner
+    function plantCells(spec) {
+        let cells = [];
+        if (spec.cells && spec.cells.length) {
+            // Shaped plants are mirrored, then turned clockwise a quarter at a time
+            cells = spec.cells.map(cell => [cell[0], cell[1]]);
+            if (spec.mirror && $('#mirror-plant').is(':checked')) {
+                cells = cells.map(([r, c]) => [r, -c]);
+            }
+            for (let turn = 0; turn < plantRotation; turn += 90) {
+                cells = cells.map(([r, c]) => [c, -r]);
+            }
+        } else {
+            // Rotated plants are laid as height x width
+            let width = spec.width;
+            let height = spec.height;
+            if ($('input[name="orientation"]:checked').val() === 'vertical') {
+                width = spec.height;
+                height = spec.width;
+            }
+            for (let r = 0; r < height; r++) {
+                for (let c = 0; c < width; c++) {
+                    cells.push([r, c]);
+                }
+            }
+        }
+        
+        const minRow = Math.min(...cells.map(cell => cell[0]));
+        const minCol = Math.min(...cells.map(cell => cell[1]));
+        return cells.map(([r, c]) => [r - minRow, c - minCol]);
+    }
+    
+    // Function to find a plant type in the game catalog
+    function plantSpec(type) {
+        return (plantCatalog || classicCatalog).plants.find(plant => plant.type === type);
+    }
+    
+    // Function to draw a plant, with its picture or its catalog symbol
+    function plantIcon(type) {
+        type = type.toUpperCase();
+        if (plantImages.includes(type)) {
+            return `<img src="assets/img/${type.toLowerCase()}.png" alt="${type}">`;
+        }
+        const spec = plantSpec(type);
+        const symbol = (spec && spec.symbol) || type.charAt(0);
+        return `<span class="plant-symbol" title="${type}">${symbol}</span>`;
+    }
+    
+    // Function to list the plants of the catalog in the plant selector
+    function renderPlantSelector() {
+        const selector = $('.plant-selector');
+        selector.empty();
+        plantCatalog.plants.forEach(plant => {
+            const name = plant.type.charAt(0) + plant.type.slice(1).toLowerCase();
+            const count = plant.count ? `<span>max ${plant.count}</span>` : '';
+            selector.append(`
+                <div class="plant-option" data-type="${plant.type}">
+                    ${plantIcon(plant.type)}
+                    <span>${name}</span>
+                    <span>${plant.capacity} MW</span>
+                    <span>${plant.width}x${plant.height}</span>
+                    ${count}
+                </div>
+            `);
+        });
+    }
+    
     // Plant selection event
-    $('.plant-option').click(function() {
+    $('.plant-selector').on('click', '.plant-option', function() {
         $('.plant-option').removeClass('selected');
         $(this).addClass('selected');
         selectedPlantType = $(this).data('type');
         
-        // Show/hide orientation selector for Wind plants
-        if (selectedPlantType === 'WIND') {
+        // Show the orientation selector for rectangles that can be rotated,
+        // and the shape selector for shaped plants
+        const spec = plantSpec(selectedPlantType);
+        $('#orientation-selector').hide();
+        $('#shape-selector').hide();
+        if (spec && spec.cells && spec.cells.length) {
+            plantRotation = 0;
+            $('#plant-rotation').text(plantRotation);
+            $('#mirror-plant').prop('checked', false);
+            $('#mirror-option').toggle(!!spec.mirror);
+            $('#shape-selector').show();
+        } else if (spec && spec.width !== spec.height) {
             $('#orientation-selector').show();
-        } else {
-            $('#orientation-selector').hide();
         }
     });
     
@@ -815,7 +895,7 @@ function strikeOpponent(coord, targetOpponent) {
         const opponentIndex = parseInt(boardId.replace('opponent', '').replace('-board', '')) - 1;
         
         // Get the opponents list
-        const opponents = Object.keys(gameData.players).filter(name => name !== playerName);
+        const opponents = Object.keys(gameData.players).filter(name => isOpponent(gameData.players, name));
         
         // Get the target opponent name
         const targetOpponent = opponents[opponentIndex];
@@ -872,13 +952,56 @@ function strikeOpponent(coord, targetOpponent) {
         });
     });
     
-    // Hide orientation selector initially (only show for Wind plants)
+    // Hide orientation and shape selectors initially (only show for plants
+    // that can be rotated)
     $('#orientation-selector').hide();
+    $('#shape-selector').hide();
+    
+    // Function to turn the selected shaped plant a quarter clockwise
+    function rotateShape() {
+        plantRotation = (plantRotation + 90) % 360;
+        $('#plant-rotation').text(plantRotation);
+    }
+    
+    $('#rotate-plant-btn').on('click', rotateShape);
+    
+    // Rotate the selected plant with the R key, mirror it with the M key
+    $(document).on('keydown', function(e) {
+        if ($(e.target).is('input[type="text"], textarea')) return;
+        
+        if (e.key === 'm' || e.key === 'M') {
+            if ($('#mirror-option').is(':visible')) {
+                $('#mirror-plant').prop('checked', !$('#mirror-plant').is(':checked'));
+            }
+            return;
+        }
+        if (e.key !== 'r' && e.key !== 'R') return;
+        
+        if ($('#shape-selector').is(':visible')) {
+            rotateShape();
+            return;
+        }
+        if (!$('#orientation-selector').is(':visible')) return;
+        
+        const current = $('input[name="orientation"]:checked').val();
+        const next = current === 'vertical' ? 'horizontal' : 'vertical';
+        $(`input[name="orientation"][value="${next}"]`).prop('checked', true);
+    });
     
     // Initial update
     updateGameData();
     
-    // Set up polling
+    // Receive game changes as they happen
+    let gameSocket = { open: false };
+    try {
+        gameSocket = connectGameSocket(gameId, function(message) {
+            updateGameData();
+        }, playerName, getPlayerToken());
+    } catch (error) {
+        console.error('Token error, using polling only:', error);
+    }
+    
+    // Set up polling, used while the socket is not connected
     let pollingInterval;
     
     function startPolling() {
@@ -895,6 +1018,11 @@ function strikeOpponent(coord, targetOpponent) {
                 clearInterval(pollingInterval);
                 return;
             }
+            
+            // Changes are pushed while the socket is connected
+            if (gameSocket.open) {
+                return;
+            }
             updateGameData();
             
             // Adjust polling frequency if game status changes
@@ -0,0 +1,45 @@
+// Game notifications over WebSocket
+
+// Function to open a WebSocket that pushes game changes.
+// onMessage is called for every message received. While connection.open is
+// false the socket is not available and the page should fall back to polling.
+function connectGameSocket(gameId, onMessage, playerName = null, token = null) {
+    const connection = { open: false };
+    
+    // Browsers without WebSocket support keep polling
+    if (!window.WebSocket) {
+        return connection;
+    }
+    
+    const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+    let url = `${protocol}//${window.location.host}/api/games/${gameId}/ws`;
+    if (playerName && token) {
+        url += `?player=${encodeURIComponent(playerName)}&token=${encodeURIComponent(token)}`;
+    }
+    
+    function connect() {
+        const socket = new WebSocket(url);
+        
+        socket.onopen = function() {
+            connection.open = true;
+        };
+        
+        socket.onmessage = function(event) {
+            try {
+                onMessage(JSON.parse(event.data));
+            } catch (error) {
+                console.error('Error handling game message:', error);
+            }
+        };
+        
+        socket.onclose = function() {
+            // Fall back to polling and try to reconnect later
+            connection.open = false;
+            setTimeout(connect, 5000);
+        };
+    }
+    
+    connect();
+    
+    return connection;
+}
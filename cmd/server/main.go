@@ -1,17 +1,25 @@
 package main
 
 import (
+	"context"
 	"embed"
+	"encoding/json"
+	"flag"
+	"fmt"
 	"io/fs"
 	"log"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	echoSwagger "github.com/swaggo/echo-swagger"
+	"github.com/xorduna/energywar/pkg/auth"
 	"github.com/xorduna/energywar/pkg/game"
 	"github.com/xorduna/energywar/pkg/handlers"
+	"github.com/xorduna/energywar/pkg/store"
 
 	_ "github.com/xorduna/energywar/docs" // Import generated swagger docs
 )
@@ -27,40 +35,105 @@ var frontendFS embed.FS
 // @version 1.0
 // @description API for the Energy War Game
 // @BasePath /api
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Player token as "Bearer <token>"
 func main() {
+	// Parse command line flags
+	storeType := flag.String("store", "memory", "Game store backend (memory, sqlite, postgres)")
+	dbPath := flag.String("db", "energywar.db", "SQLite database file")
+	dsn := flag.String("dsn", os.Getenv("DATABASE_URL"), "PostgreSQL connection string")
+	jwtSecret := flag.String("jwt-secret", os.Getenv("JWT_SECRET"), "Secret to sign player tokens as JWTs (random tokens if empty)")
+	jwtTTL := flag.Duration("jwt-ttl", 2*time.Hour, "Lifetime of the signed player tokens")
+	catalogs := flag.String("catalogs", "", "Comma separated YAML or JSON plant catalog files games can be created with")
+	flag.Parse()
+
+	// Open the game store
+	gameStore, err := openStore(*storeType, *dbPath, *dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer gameStore.Close()
+
 	// Create a new Echo instance
 	e := echo.New()
 
 	// Middleware
-	e.Use(middleware.Logger())
+	e.Use(requestLogger())
 	e.Use(middleware.Recover())
 	//e.Use(middleware.CORS())
 
 	// Create game manager
-	gameManager := game.NewGameManager()
+	gameManager := game.NewGameManager(gameStore)
+
+	// Load the plant catalogs besides the built-in ones
+	if *catalogs != "" {
+		for _, path := range strings.Split(*catalogs, ",") {
+			catalog, err := game.LoadCatalog(strings.TrimSpace(path))
+			if err != nil {
+				log.Fatalf("failed to load plant catalog %s: %v", path, err)
+			}
+			if err := gameManager.AddCatalog(catalog); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	// Skip the turns that run out of time
+	go gameManager.RunTurnTimers(context.Background(), time.Second)
 
 	// Create handler
 	handler := handlers.NewHandler(gameManager)
+	if *jwtSecret != "" {
+		if len(*jwtSecret) < 32 {
+			log.Fatal("the JWT secret should be at least 32 characters long")
+		}
+		handler.Tokens = auth.NewSigner([]byte(*jwtSecret), *jwtTTL)
+	}
 
 	// API Group
 	api := e.Group("/api")
 
 	// Game routes
 	api.POST("/games", handler.CreateGame)
+	api.GET("/games", handler.ListGames)
+	api.GET("/catalogs", handler.ListCatalogs)
 	api.GET("/games/:id", handler.GetGame)
 	api.GET("/games/:id/status", handler.GetGameStatus)
 	api.POST("/games/:id/join", handler.JoinGame)
+	api.POST("/games/:id/bots", handler.AddBot)
+	api.GET("/games/:id/replay", handler.GetReplay)
+	api.GET("/games/:id/ws", handler.GameSocket)
+	api.GET("/games/:id/events", handler.GameEvents)
+	api.POST("/games/:id/spectate", handler.Spectate)
+	api.GET("/games/:id/spectate", handler.GetSpectatorView)
+
+	// Account routes
+	api.POST("/players", handler.RegisterPlayer)
+	api.GET("/players/:name", handler.GetPlayer)
+	api.GET("/leaderboard", handler.GetLeaderboard)
+
+	// Matchmaking routes
+	api.POST("/matchmaking/queue", handler.JoinQueue)
+	api.GET("/matchmaking/queue/:ticket", handler.GetQueueStatus)
+	api.DELETE("/matchmaking/queue/:ticket", handler.LeaveQueue)
 
 	// Player routes
-	api.POST("/games/:id/players/:name/ready", handler.SetPlayerReady)
-	api.POST("/games/:id/players/:name/strike", handler.Strike)
-	api.POST("/games/:id/players/:name/board", handler.SetBoard)
-	api.GET("/games/:id/players/:name/board", handler.GetBoard)
-	api.GET("/games/:id/players/:name/board/map", handler.GetBoardMap)
+	players := api.Group("/games/:id/players/:name", handler.RequirePlayer)
+	players.POST("/ready", handler.SetPlayerReady)
+	players.POST("/strike", handler.Strike)
+	players.POST("/board", handler.SetBoard)
+	players.GET("/board", handler.GetBoard)
+	players.GET("/board/map", handler.GetBoardMap)
+	players.GET("/wait", handler.WaitForTurn)
+	players.POST("/token", handler.RefreshToken)
 
 	// Opponent routes
 	api.GET("/games/:id/opponent/:name/board", handler.GetOpponentBlindBoard)
 	api.GET("/games/:id/opponent/:name/board/map", handler.GetOpponentBoardMap)
+	api.GET("/games/:id/opponent/:name/heatmap", handler.GetOpponentHeatmap)
+	api.GET("/games/:id/opponent/:name/heatmap/map", handler.GetOpponentHeatmapMap)
 
 	// Swagger documentation
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
@@ -86,3 +159,60 @@ func main() {
 	// Start server
 	e.Logger.Fatal(e.Start(":8080"))
 }
+
+// openStore creates the game store selected on the command line
+func openStore(storeType string, dbPath string, dsn string) (store.GameStore, error) {
+	switch storeType {
+	case "memory":
+		return store.NewMemoryStore(), nil
+	case "sqlite":
+		return store.NewSQLiteStore(dbPath)
+	case "postgres":
+		return store.NewPostgresStore(dsn)
+	default:
+		return nil, fmt.Errorf("unknown store: %s", storeType)
+	}
+}
+
+// requestLogger logs every request as a JSON line, like Echo's default
+// logger, with the tokens and keys in the URI redacted
+func requestLogger() echo.MiddlewareFunc {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+
+	return middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+		LogRequestID:     true,
+		LogRemoteIP:      true,
+		LogHost:          true,
+		LogMethod:        true,
+		LogURI:           true,
+		LogUserAgent:     true,
+		LogStatus:        true,
+		LogError:         true,
+		LogLatency:       true,
+		LogContentLength: true,
+		LogResponseSize:  true,
+		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
+			errMsg := ""
+			if v.Error != nil {
+				errMsg = v.Error.Error()
+			}
+
+			return encoder.Encode(map[string]interface{}{
+				"time":          v.StartTime.Format(time.RFC3339Nano),
+				"id":            v.RequestID,
+				"remote_ip":     v.RemoteIP,
+				"host":          v.Host,
+				"method":        v.Method,
+				"uri":           handlers.RedactURI(v.URI),
+				"user_agent":    v.UserAgent,
+				"status":        v.Status,
+				"error":         errMsg,
+				"latency":       v.Latency.Nanoseconds(),
+				"latency_human": v.Latency.String(),
+				"bytes_in":      v.ContentLength,
+				"bytes_out":     v.ResponseSize,
+			})
+		},
+	})
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strings"
+
+	"github.com/xorduna/energywar/pkg/bot"
+	"github.com/xorduna/energywar/pkg/game"
+	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
+)
+
+// player is a bot strategy taking part in the simulation
+type player struct {
+	Level    bot.Level
+	Strategy bot.Strategy
+	Stats    *Stats
+}
+
+// result summarizes how a strategy did in a single game. Turns counts the
+// turns of every player in the game, Shots only the ones of the strategy.
+type result struct {
+	Won    bool
+	Turns  int
+	Shots  int
+	Plants int
+}
+
+// Simulate plays bot-vs-bot games directly through the game manager (no HTTP)
+// and reports how each strategy performs. With the same seed and parameters
+// the results are always the same.
+func main() {
+	// Parse command line flags
+	strategies := flag.String("strategies", "easy,medium,hard", "Comma separated bot levels playing each game (2-4)")
+	games := flag.Int("games", 100, "Number of games to simulate")
+	seed := flag.Int64("seed", 1, "Random seed")
+	size := flag.Int("size", 10, "Board size (5-20)")
+	capacity := flag.Int("capacity", 1000, "Required capacity")
+	catalogFlag := flag.String("catalog", models.DefaultCatalog.Name, "Plant catalog, a built-in catalog name or a YAML or JSON catalog file")
+	partialDamage := flag.Bool("partial-damage", false, "Each cell hit takes its share of the plant capacity")
+	weather := flag.Bool("weather", false, "The weather of each round scales the output of WIND and SOLAR plants")
+	format := flag.String("format", "text", "Output format (text, json)")
+	flag.Parse()
+
+	levels := strings.Split(*strategies, ",")
+	if len(levels) < 2 || len(levels) > 4 {
+		log.Fatal("between 2 and 4 strategies are needed")
+	}
+	if *games <= 0 {
+		log.Fatal("games should be greater than 0")
+	}
+
+	// Every random decision comes from the seed
+	rng := rand.New(rand.NewSource(*seed))
+	gm := game.NewGameManager(store.NewMemoryStore())
+	gm.SetRandom(rand.New(rand.NewSource(rng.Int63())))
+
+	// Catalog files are added to the built-in catalogs
+	catalog := *catalogFlag
+	if _, err := os.Stat(catalog); err == nil {
+		loaded, err := game.LoadCatalog(catalog)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := gm.AddCatalog(loaded); err != nil {
+			log.Fatal(err)
+		}
+		catalog = loaded.Name
+	}
+
+	players := make([]*player, len(levels))
+	for i, level := range levels {
+		level = strings.TrimSpace(level)
+		strategy, err := bot.NewStrategy(bot.Level(level), rand.New(rand.NewSource(rng.Int63())))
+		if err != nil {
+			log.Fatal(err)
+		}
+		players[i] = &player{
+			Level:    bot.Level(level),
+			Strategy: strategy,
+			Stats:    &Stats{Strategy: fmt.Sprintf("%d-%s", i+1, level)},
+		}
+	}
+
+	// Play the games, rotating the seats so no strategy always plays first
+	for i := 0; i < *games; i++ {
+		seats := make([]*player, len(players))
+		for j := range players {
+			seats[j] = players[(i+j)%len(players)]
+		}
+
+		results, err := playGame(gm, seats, models.GameOptions{
+			Size:          *size,
+			Capacity:      *capacity,
+			Catalog:       catalog,
+			PartialDamage: *partialDamage,
+			Weather:       *weather,
+		})
+		if err != nil {
+			log.Fatalf("game %d: %v", i+1, err)
+		}
+		for j, p := range seats {
+			p.Stats.Add(results[j])
+		}
+	}
+
+	// Report the results
+	report := Report{
+		Games:         *games,
+		Seed:          *seed,
+		Size:          *size,
+		Capacity:      *capacity,
+		Catalog:       catalog,
+		PartialDamage: *partialDamage,
+		Weather:       *weather,
+	}
+	for _, p := range players {
+		report.Strategies = append(report.Strategies, p.Stats.Summary())
+	}
+
+	switch *format {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatal(err)
+		}
+	case "text":
+		report.WriteText(os.Stdout)
+	default:
+		log.Fatalf("unknown format: %s", *format)
+	}
+}
+
+// playGame plays a full game between the seated players and returns the
+// result of each of them
+func playGame(gm *game.GameManager, seats []*player, opts models.GameOptions) ([]result, error) {
+	gameObj, err := gm.CreateGame(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Everybody joins before getting ready, the game starts as soon as
+	// all the joined players are ready
+	bots := make([]*bot.Bot, len(seats))
+	seatOf := make(map[string]int)
+	for i, p := range seats {
+		name := fmt.Sprintf("p%d-%s", i+1, p.Level)
+		if _, err := gm.JoinGame(gameObj.ID, name, 0); err != nil {
+			return nil, err
+		}
+		bots[i] = bot.New(gm, gameObj.ID, name, p.Strategy)
+		seatOf[name] = i
+	}
+	for _, b := range bots {
+		if err := b.Ready(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Take turns until the game ends
+	maxShots := len(seats) * (len(seats) - 1) * opts.Size * opts.Size
+	for shots := 0; ; shots++ {
+		gameObj, err = gm.GetGame(gameObj.ID)
+		if err != nil {
+			return nil, err
+		}
+		if gameObj.Status == models.GameStatusEnd {
+			break
+		}
+		if shots > maxShots {
+			return nil, errors.New("game did not end")
+		}
+
+		if err := bots[seatOf[gameObj.Turn]].Strike(gameObj); err != nil {
+			return nil, err
+		}
+	}
+
+	// Collect the results from the event log
+	results := make([]result, len(seats))
+	turns := 0
+	for _, event := range gameObj.Events {
+		if event.Type != models.EventStrikeResolved {
+			continue
+		}
+		turns++
+		seat := seatOf[event.Player]
+		results[seat].Shots++
+		// Partial damage games tell when the last cell of a plant is hit,
+		// otherwise a hit destroys the whole plant
+		if event.Result == "DESTROYED" || (event.Result == "HIT" && !gameObj.PartialDamage) {
+			results[seat].Plants++
+		}
+	}
+	for i := range results {
+		results[i].Turns = turns
+	}
+	if gameObj.Winner != nil {
+		results[seatOf[*gameObj.Winner]].Won = true
+	}
+
+	return results, nil
+}
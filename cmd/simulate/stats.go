@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"text/tabwriter"
+)
+
+// z95 is the z-score of a 95% confidence interval
+const z95 = 1.96
+
+// t95 are the two-sided 95% quantiles of Student's t distribution by degrees
+// of freedom, used instead of z95 for means of up to 30 samples
+var t95 = []float64{
+	0, 12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262,
+	2.228, 2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093,
+	2.086, 2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045,
+}
+
+// Stats accumulates the results of a strategy across games
+type Stats struct {
+	Strategy string
+
+	games         int
+	wins          int
+	turnsToWin    []float64
+	shotsToWin    []float64
+	shotsPerPlant []float64
+	noPlantGames  int
+}
+
+// Add records the result of a game
+func (s *Stats) Add(r result) {
+	s.games++
+	if r.Won {
+		s.wins++
+		s.turnsToWin = append(s.turnsToWin, float64(r.Turns))
+		s.shotsToWin = append(s.shotsToWin, float64(r.Shots))
+	}
+	if r.Plants > 0 {
+		s.shotsPerPlant = append(s.shotsPerPlant, float64(r.Shots)/float64(r.Plants))
+	} else {
+		s.noPlantGames++
+	}
+}
+
+// Interval represents a 95% confidence interval
+type Interval struct {
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+}
+
+// Mean represents an average with its 95% confidence interval
+type Mean struct {
+	Value    float64  `json:"value"`
+	Interval Interval `json:"ci95"`
+	Samples  int      `json:"samples"`
+}
+
+// StrategyReport summarizes the performance of a strategy. TurnsToWin
+// counts the turns of every player in the games won, ShotsToWin only the
+// shots of the winner. ShotsPerPlant leaves out the NoPlantGames in which
+// the strategy destroyed no plant, which makes it lower than it would be
+// when there are many of them.
+type StrategyReport struct {
+	Strategy      string   `json:"strategy"`
+	Games         int      `json:"games"`
+	Wins          int      `json:"wins"`
+	WinRate       float64  `json:"win_rate"`
+	WinRateCI     Interval `json:"win_rate_ci95"`
+	TurnsToWin    Mean     `json:"turns_to_win"`
+	ShotsToWin    Mean     `json:"shots_to_win"`
+	ShotsPerPlant Mean     `json:"shots_per_plant"`
+	NoPlantGames  int      `json:"no_plant_games"`
+}
+
+// Report represents the results of a simulation
+type Report struct {
+	Games         int              `json:"games"`
+	Seed          int64            `json:"seed"`
+	Size          int              `json:"size"`
+	Capacity      int              `json:"capacity"`
+	Catalog       string           `json:"catalog"`
+	PartialDamage bool             `json:"partial_damage"`
+	Weather       bool             `json:"weather"`
+	Strategies    []StrategyReport `json:"strategies"`
+}
+
+// Summary computes the report of the strategy
+func (s *Stats) Summary() StrategyReport {
+	report := StrategyReport{
+		Strategy:      s.Strategy,
+		Games:         s.games,
+		Wins:          s.wins,
+		TurnsToWin:    mean(s.turnsToWin),
+		ShotsToWin:    mean(s.shotsToWin),
+		ShotsPerPlant: mean(s.shotsPerPlant),
+		NoPlantGames:  s.noPlantGames,
+	}
+	if s.games > 0 {
+		report.WinRate = float64(s.wins) / float64(s.games)
+		report.WinRateCI = wilson(s.wins, s.games)
+	}
+
+	return report
+}
+
+// wilson computes the Wilson score interval of a proportion
+func wilson(successes int, n int) Interval {
+	p := float64(successes) / float64(n)
+	z2 := z95 * z95
+	center := (p + z2/(2*float64(n))) / (1 + z2/float64(n))
+	margin := z95 * math.Sqrt(p*(1-p)/float64(n)+z2/(4*float64(n)*float64(n))) / (1 + z2/float64(n))
+
+	return Interval{Low: center - margin, High: center + margin}
+}
+
+// mean computes the average of the samples with a 95% interval, from
+// Student's t distribution for up to 30 samples and the normal one
+// otherwise. The samples are counts, so the interval does not go below 0.
+func mean(samples []float64) Mean {
+	m := Mean{Samples: len(samples)}
+	if len(samples) == 0 {
+		return m
+	}
+
+	sum := 0.0
+	for _, v := range samples {
+		sum += v
+	}
+	m.Value = sum / float64(len(samples))
+
+	if len(samples) > 1 {
+		variance := 0.0
+		for _, v := range samples {
+			variance += (v - m.Value) * (v - m.Value)
+		}
+		variance /= float64(len(samples) - 1)
+		z := z95
+		if df := len(samples) - 1; df < len(t95) {
+			z = t95[df]
+		}
+		margin := z * math.Sqrt(variance/float64(len(samples)))
+		m.Interval = Interval{Low: math.Max(m.Value-margin, 0), High: m.Value + margin}
+	} else {
+		m.Interval = Interval{Low: m.Value, High: m.Value}
+	}
+
+	return m
+}
+
+// WriteText writes the report as a table
+func (r Report) WriteText(w io.Writer) {
+	rules := ""
+	if r.PartialDamage {
+		rules += ", partial damage"
+	}
+	if r.Weather {
+		rules += ", weather"
+	}
+	fmt.Fprintf(w, "Simulated %d games (size %d, capacity %d, catalog %s%s, seed %d)\n\n", r.Games, r.Size, r.Capacity, r.Catalog, rules, r.Seed)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STRATEGY\tWINS\tWIN RATE (95% CI)\tTURNS TO WIN (95% CI)\tSHOTS TO WIN (95% CI)\tSHOTS PER PLANT (95% CI)")
+	for _, s := range r.Strategies {
+		fmt.Fprintf(tw, "%s\t%d/%d\t%.1f%% [%.1f%%, %.1f%%]\t%s\t%s\t%s\n",
+			s.Strategy, s.Wins, s.Games,
+			s.WinRate*100, s.WinRateCI.Low*100, s.WinRateCI.High*100,
+			formatMean(s.TurnsToWin), formatMean(s.ShotsToWin), formatShotsPerPlant(s))
+	}
+	tw.Flush()
+}
+
+// formatMean formats an average with its interval
+func formatMean(m Mean) string {
+	if m.Samples == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f [%.1f, %.1f]", m.Value, m.Interval.Low, m.Interval.High)
+}
+
+// formatShotsPerPlant formats the shots per plant with the number of games
+// left out of the average
+func formatShotsPerPlant(s StrategyReport) string {
+	if s.NoPlantGames == 0 {
+		return formatMean(s.ShotsPerPlant)
+	}
+	return fmt.Sprintf("%s, %d games excluded", formatMean(s.ShotsPerPlant), s.NoPlantGames)
+}
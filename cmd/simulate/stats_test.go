@@ -0,0 +1,84 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// near reports whether two values agree to the precision of the tables
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-3
+}
+
+func TestWilson(t *testing.T) {
+	tests := []struct {
+		successes, n int
+		expected     Interval
+	}{
+		{successes: 0, n: 10, expected: Interval{Low: 0, High: 0.2775}},
+		{successes: 5, n: 10, expected: Interval{Low: 0.2366, High: 0.7634}},
+		{successes: 10, n: 10, expected: Interval{Low: 0.7225, High: 1}},
+		{successes: 1, n: 1, expected: Interval{Low: 0.2065, High: 1}},
+		{successes: 50, n: 100, expected: Interval{Low: 0.4038, High: 0.5962}},
+	}
+	for _, test := range tests {
+		interval := wilson(test.successes, test.n)
+		if !near(interval.Low, test.expected.Low) || !near(interval.High, test.expected.High) {
+			t.Fatalf("%d/%d: expected %+v, got %+v", test.successes, test.n, test.expected, interval)
+		}
+	}
+}
+
+func TestMean(t *testing.T) {
+	// alternating returns n samples of 0 and 2, whose mean is 1 and
+	// standard error is 1/sqrt(n-1)
+	alternating := func(n int) []float64 {
+		samples := make([]float64, n)
+		for i := range samples {
+			samples[i] = float64(2 * (i % 2))
+		}
+		return samples
+	}
+	halfWidth := func(q float64, n int) float64 {
+		return q / math.Sqrt(float64(n-1))
+	}
+
+	tests := []struct {
+		name     string
+		samples  []float64
+		expected Mean
+	}{
+		{name: "no samples", expected: Mean{}},
+		{name: "one sample", samples: []float64{3}, expected: Mean{Value: 3, Interval: Interval{Low: 3, High: 3}, Samples: 1}},
+		// t with 2 degrees of freedom, cut at 0
+		{name: "three samples", samples: []float64{1, 2, 3}, expected: Mean{Value: 2, Interval: Interval{Low: 0, High: 2 + 4.303/math.Sqrt(3)}, Samples: 3}},
+		// t with 1 degree of freedom
+		{name: "two samples", samples: alternating(2), expected: Mean{Value: 1, Interval: Interval{Low: 0, High: 1 + halfWidth(12.706, 2)}, Samples: 2}},
+		// t with 29 degrees of freedom, the last of the table
+		{name: "thirty samples", samples: alternating(30), expected: Mean{Value: 1, Interval: Interval{Low: 1 - halfWidth(2.045, 30), High: 1 + halfWidth(2.045, 30)}, Samples: 30}},
+		// normal from 31 samples on
+		{name: "thirty one samples", samples: append(alternating(30), 1), expected: Mean{Value: 1, Interval: Interval{Low: 1 - 1.96*math.Sqrt(30.0/30/31), High: 1 + 1.96*math.Sqrt(30.0/30/31)}, Samples: 31}},
+	}
+	for _, test := range tests {
+		m := mean(test.samples)
+		if m.Samples != test.expected.Samples || !near(m.Value, test.expected.Value) ||
+			!near(m.Interval.Low, test.expected.Interval.Low) || !near(m.Interval.High, test.expected.Interval.High) {
+			t.Fatalf("%s: expected %+v, got %+v", test.name, test.expected, m)
+		}
+	}
+}
+
+func TestSummaryCountsGamesWithoutPlants(t *testing.T) {
+	stats := &Stats{Strategy: "hard"}
+	stats.Add(result{Won: true, Turns: 20, Shots: 10, Plants: 5})
+	stats.Add(result{Won: false, Turns: 30, Shots: 15, Plants: 0})
+	stats.Add(result{Won: false, Turns: 30, Shots: 15, Plants: 5})
+
+	report := stats.Summary()
+	if report.ShotsPerPlant.Samples != 2 || report.NoPlantGames != 1 {
+		t.Fatalf("expected 2 games in the shots per plant and 1 left out, got %d and %d", report.ShotsPerPlant.Samples, report.NoPlantGames)
+	}
+	if !near(report.ShotsPerPlant.Value, 2.5) {
+		t.Fatalf("expected 2.5 shots per plant, got %v", report.ShotsPerPlant.Value)
+	}
+}
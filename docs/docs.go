@@ -15,7 +15,94 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/catalogs": {
+            "get": {
+                "description": "Lists the plant catalogs games can be created with, each defining the type, capacity, size and allowed count of its plants",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "games"
+                ],
+                "summary": "List the plant catalogs",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PlantCatalog"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/games": {
+            "get": {
+                "description": "Lists the public games, oldest first and paginated with a cursor",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "games"
+                ],
+                "summary": "List public games",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Game status (PENDING, IN_PROGRESS, END)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Board size",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Only games that can still be joined",
+                        "name": "has_seat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Games per page (max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LobbyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
             "post": {
                 "description": "Creates a new game with the specified parameters",
                 "consumes": [
@@ -42,6 +129,88 @@ const docTemplate = `{
                         "description": "Required capacity",
                         "name": "capacity",
                         "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Public game, boards are visible to everyone (only once the game ends with delayed spectators)",
+                        "name": "public",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Training game, enables coaching tools such as the opponent heatmap",
+                        "name": "training",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Elimination game, knocked out players are out and the game goes on until a single player is left",
+                        "name": "elimination",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "classic",
+                        "description": "Plant catalog the game is played with, see /catalogs",
+                        "name": "catalog",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Team game, four players play 2v2 until a team is knocked out",
+                        "name": "teams",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Each cell hit takes its share of the plant capacity, plants are destroyed when every cell is hit",
+                        "name": "partial_damage",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "The weather of each round scales the output of WIND and SOLAR plants",
+                        "name": "weather",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Seed of the weather, random by default",
+                        "name": "weather_seed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "blind",
+                        "description": "What spectators see (blind, full, delayed)",
+                        "name": "spectators",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 3,
+                        "description": "Moves the delayed spectator view is behind",
+                        "name": "spectator_delay",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time limit of a turn, as a duration (60s, 2m) or in seconds; no limit by default",
+                        "name": "turn_timeout",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 3,
+                        "description": "Expired turns in a row after which a player forfeits",
+                        "name": "max_timeouts",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -98,6 +267,121 @@ const docTemplate = `{
                 }
             }
         },
+        "/games/{id}/bots": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Adds a computer opponent that joins the game, places a board, gets ready and plays its turns automatically until the game ends. Only the players of the game can add bots, with their token or the API key of their account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "games"
+                ],
+                "summary": "Add a bot to a game",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "easy",
+                        "description": "Bot level (easy, medium, hard)",
+                        "name": "level",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AddBotResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/games/{id}/events": {
+            "get": {
+                "description": "Streams the changes of a game as Server-Sent Events: player_joined, player_ready, game_started, strike_result, turn_change and game_over. Each event id is the sequence number of the change, so a reconnecting client sending Last-Event-ID (or the last_event_id query parameter) receives every change it missed.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "games"
+                ],
+                "summary": "Game notifications as Server-Sent Events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Last event ID received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Last event ID received",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.GameMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/games/{id}/join": {
             "post": {
                 "description": "Allows a player to join an existing game",
@@ -125,6 +409,18 @@ const docTemplate = `{
                         "name": "player",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key of the player account, also accepted in the X-API-Key header",
+                        "name": "key",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Team to join in team games (1 or 2), the smallest team by default",
+                        "name": "team",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -139,6 +435,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
                     }
                 }
             }
@@ -233,9 +541,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/games/{id}/players/{name}/board": {
+        "/games/{id}/opponent/{name}/heatmap": {
             "get": {
-                "description": "Gets a player's board configuration",
+                "description": "Gets, for each cell of an opponent's blind board, the number of possible plant placements covering it. Only available in training games.",
                 "consumes": [
                     "application/json"
                 ],
@@ -245,7 +553,7 @@ const docTemplate = `{
                 "tags": [
                     "players"
                 ],
-                "summary": "Get player board",
+                "summary": "Get opponent strike heatmap",
                 "parameters": [
                     {
                         "type": "string",
@@ -256,24 +564,23 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Player name",
+                        "description": "Opponent name",
                         "name": "name",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Player token",
-                        "name": "token",
-                        "in": "query",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Board"
+                            "$ref": "#/definitions/models.Heatmap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
@@ -283,19 +590,21 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Sets a player's board configuration",
+            }
+        },
+        "/games/{id}/opponent/{name}/heatmap/map": {
+            "get": {
+                "description": "Gets an ASCII representation of an opponent's strike heatmap, with densities scaled from 0 to 9. Only available in training games.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "text/plain"
                 ],
                 "tags": [
                     "players"
                 ],
-                "summary": "Set player board",
+                "summary": "Get opponent strike heatmap map",
                 "parameters": [
                     {
                         "type": "string",
@@ -306,18 +615,139 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Player name",
+                        "description": "Opponent name",
                         "name": "name",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Player token",
-                        "name": "token",
-                        "in": "query",
-                        "required": true
-                    },
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/games/{id}/players/{name}/board": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Gets a player's board configuration, or the board of a teammate in team games",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "players"
+                ],
+                "summary": "Get player board",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Player name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Teammate whose board to get",
+                        "name": "teammate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Player token (deprecated, send it in the Authorization header)",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Board"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sets a player's board configuration",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "players"
+                ],
+                "summary": "Set player board",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Player name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Player token (deprecated, send it in the Authorization header)",
+                        "name": "token",
+                        "in": "query"
+                    },
                     {
                         "description": "Board configuration",
                         "name": "board",
@@ -346,12 +776,23 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
         "/games/{id}/players/{name}/board/map": {
             "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
                 "description": "Gets an ASCII representation of a player's board",
                 "consumes": [
                     "application/json"
@@ -380,10 +821,9 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Player token",
+                        "description": "Player token (deprecated, send it in the Authorization header)",
                         "name": "token",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -410,6 +850,11 @@ const docTemplate = `{
         },
         "/games/{id}/players/{name}/ready": {
             "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
                 "description": "Sets a player as ready to start the game",
                 "consumes": [
                     "application/json"
@@ -438,10 +883,9 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Player token",
+                        "description": "Player token (deprecated, send it in the Authorization header)",
                         "name": "token",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -462,12 +906,23 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
         "/games/{id}/players/{name}/strike": {
             "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
                 "description": "Player strikes a coordinate on the opponent's board",
                 "consumes": [
                     "application/json"
@@ -496,10 +951,9 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Player token",
+                        "description": "Player token (deprecated, send it in the Authorization header)",
                         "name": "token",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
                     },
                     {
                         "type": "string",
@@ -541,13 +995,74 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/games/{id}/status": {
+        "/games/{id}/players/{name}/token": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Issues a new signed token with a fresh expiry. Without signed tokens the current token is returned, as it does not expire.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "players"
+                ],
+                "summary": "Refresh a player token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Player name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.JoinGameResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/games/{id}/players/{name}/wait": {
             "get": {
-                "description": "Gets the limited status of a game",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Long poll that blocks until it is the player's turn or the game has ended, or until the timeout expires. Returns the limited game view and the latest strike against the player.",
                 "consumes": [
                     "application/json"
                 ],
@@ -555,9 +1070,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "games"
+                    "players"
                 ],
-                "summary": "Get game status",
+                "summary": "Wait for the player's turn",
                 "parameters": [
                     {
                         "type": "string",
@@ -565,13 +1080,45 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Player name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Player token (deprecated, send it in the Authorization header)",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "30s",
+                        "description": "Maximum time to wait (e.g. 30s, max 120s)",
+                        "name": "timeout",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Game"
+                            "$ref": "#/definitions/models.WaitResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
@@ -582,94 +1129,936 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.JoinGameResponse": {
-            "type": "object",
-            "properties": {
-                "token": {
-                    "type": "string"
-                }
-            }
         },
-        "models.Board": {
-            "type": "object",
-            "properties": {
-                "capacity": {
-                    "type": "integer"
-                },
-                "hits": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
+        "/games/{id}/replay": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
                     }
-                },
-                "misses": {
+                ],
+                "description": "Lists the moves of a finished game. With step, returns every board as it was after that move (0 is the starting position). Anybody can replay a public game; private games are only replayed for their players, with their token or the API key of their account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "games"
+                ],
+                "summary": "Get game replay",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Move number",
+                        "name": "step",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key of a player account, also accepted in the X-API-Key header",
+                        "name": "key",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReplayStep"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/games/{id}/spectate": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Gets the game as spectators see it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "spectators"
+                ],
+                "summary": "Get the spectator view",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Spectator token (deprecated, send it in the Authorization header)",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SpectatorView"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Issues a read-only token to follow a game. Depending on the game settings spectators see blind boards, full boards, or full boards delayed by some moves. Full and delayed views are only offered in public games, whose boards are not secret: spectating is anonymous, so the players of the game can spectate it too.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "spectators"
+                ],
+                "summary": "Spectate a game",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SpectateResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/games/{id}/status": {
+            "get": {
+                "description": "Gets the limited status of a game",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "games"
+                ],
+                "summary": "Get game status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Game"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/games/{id}/ws": {
+            "get": {
+                "description": "Opens a WebSocket that pushes a message whenever the game changes: player_joined, player_ready, game_started, strike_result, turn_change and game_over. The first message is the current state. Players authenticate with their name and token to also receive their own board in the state. Spectators authenticate with their token only and receive a state message with the spectator view on every change. Without a token the connection is anonymous.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "games"
+                ],
+                "summary": "Game notifications over WebSocket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Player name",
+                        "name": "player",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Player or spectator token",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/models.GameMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/leaderboard": {
+            "get": {
+                "description": "Lists the registered players with the highest rating",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "players"
+                ],
+                "summary": "Get the leaderboard",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Number of players (max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.LeaderboardEntry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/matchmaking/queue": {
+            "post": {
+                "description": "Queues a player for a game with the given parameters. Once enough players are waiting a game is created and every player joins it. The response carries a ticket to check the queue; with a timeout the request waits until the player is matched.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "matchmaking"
+                ],
+                "summary": "Join the matchmaking queue",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player name",
+                        "name": "player",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key of the player account, also accepted in the X-API-Key header",
+                        "name": "key",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Board size (5-20)",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "Required capacity",
+                        "name": "capacity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 2,
+                        "description": "Number of players (2-4)",
+                        "name": "players",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Wait until matched (e.g. 30s, max 120s)",
+                        "name": "timeout",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.QueueStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/matchmaking/queue/{ticket}": {
+            "get": {
+                "description": "Returns the state of a queued player. With a timeout the request waits until the player is matched. Once matched it carries the game ID and the player token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "matchmaking"
+                ],
+                "summary": "Get matchmaking ticket status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket",
+                        "name": "ticket",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Wait until matched (e.g. 30s, max 120s)",
+                        "name": "timeout",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.QueueStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes a player that is still waiting from the queue",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "matchmaking"
+                ],
+                "summary": "Leave the matchmaking queue",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket",
+                        "name": "ticket",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReadyResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/players": {
+            "post": {
+                "description": "Registers a player name and returns its API key. Joining games with the key makes them count towards the player rating. The key is only shown once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "players"
+                ],
+                "summary": "Register a player account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player name",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RegisterResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/players/{name}": {
+            "get": {
+                "description": "Gets the rating and statistics of a registered player",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "players"
+                ],
+                "summary": "Get a player account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Account"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "bot.Level": {
+            "type": "string",
+            "enum": [
+                "easy",
+                "medium",
+                "hard"
+            ],
+            "x-enum-varnames": [
+                "LevelEasy",
+                "LevelMedium",
+                "LevelHard"
+            ]
+        },
+        "handlers.AddBotResponse": {
+            "type": "object",
+            "properties": {
+                "level": {
+                    "$ref": "#/definitions/bot.Level"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.JoinGameResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Account": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "games": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "rating": {
+                    "type": "number"
+                },
+                "wins": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Board": {
+            "type": "object",
+            "properties": {
+                "capacity": {
+                    "type": "integer"
+                },
+                "destroyed": {
+                    "description": "Destroyed lists the cells of the plants with every cell hit",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "hits": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "misses": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "plants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Plant"
+                    }
+                },
+                "total_capacity": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Game": {
+            "type": "object",
+            "properties": {
+                "catalog": {
+                    "description": "Catalog defines the plants of the game, the default catalog when nil.\nUse PlantCatalog to read it.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.PlantCatalog"
+                        }
+                    ]
+                },
+                "conditions": {
+                    "$ref": "#/definitions/models.WeatherConditions"
+                },
+                "elimination": {
+                    "description": "Elimination games go on until a single player is left, knocked out\nplayers are out of the game. Ranking is the final order of the\nplayers, winner first.",
+                    "type": "boolean"
+                },
+                "forecast": {
+                    "$ref": "#/definitions/models.WeatherConditions"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "max_timeouts": {
+                    "type": "integer"
+                },
+                "partial_damage": {
+                    "description": "PartialDamage games take a share of the capacity of a plant for each\ncell hit, instead of the whole plant at the first hit",
+                    "type": "boolean"
+                },
+                "players": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.PlayerInfo"
+                    }
+                },
+                "ranking": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "round": {
+                    "type": "integer"
+                },
+                "spectator_delay": {
+                    "type": "integer"
+                },
+                "spectators": {
+                    "description": "Spectators sets what spectators see, SpectatorDelay is the number of\nmoves the delayed view is behind",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SpectatorMode"
+                        }
+                    ]
+                },
+                "status": {
+                    "$ref": "#/definitions/models.GameStatus"
+                },
+                "teams": {
+                    "description": "Teams games are played 2v2, WinnerTeam is the team that won",
+                    "type": "boolean"
+                },
+                "training": {
+                    "type": "boolean"
+                },
+                "turn": {
+                    "type": "string"
+                },
+                "turn_deadline": {
+                    "type": "string"
+                },
+                "turn_timeout": {
+                    "description": "TurnTimeout is the time limit of a turn in seconds (0 for none).\nTurnDeadline is when the current turn expires.",
+                    "type": "integer"
+                },
+                "visibility": {
+                    "type": "boolean"
+                },
+                "weather": {
+                    "description": "Weather games scale the output of WIND and SOLAR plants with the\nweather of each round, drawn from WeatherSeed. A round ends once every\nplayer still in the game has had a turn. Forecast is the weather of\nthe next round.",
+                    "type": "boolean"
+                },
+                "winner": {
+                    "type": "string"
+                },
+                "winner_team": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.GameMessage": {
+            "type": "object",
+            "properties": {
+                "coordinate": {
+                    "type": "string"
+                },
+                "game": {
+                    "$ref": "#/definitions/models.Game"
+                },
+                "player": {
+                    "type": "string"
+                },
+                "result": {
+                    "type": "string"
+                },
+                "seq": {
+                    "type": "integer"
+                },
+                "target": {
+                    "type": "string"
+                },
+                "team": {
+                    "type": "integer"
+                },
+                "turn": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/models.MessageType"
+                },
+                "winner": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.GameStatus": {
+            "type": "string",
+            "enum": [
+                "PENDING",
+                "IN_PROGRESS",
+                "END"
+            ],
+            "x-enum-varnames": [
+                "GameStatusPending",
+                "GameStatusInProgress",
+                "GameStatusEnd"
+            ]
+        },
+        "models.Heatmap": {
+            "type": "object",
+            "properties": {
+                "cells": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "hits": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "plants": {
+                "max": {
+                    "type": "integer"
+                },
+                "misses": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Plant"
+                        "type": "string"
                     }
                 },
-                "total_capacity": {
+                "size": {
                     "type": "integer"
                 }
             }
         },
-        "models.ErrorResponse": {
+        "models.LeaderboardEntry": {
             "type": "object",
             "properties": {
-                "error": {
+                "created_at": {
                     "type": "string"
                 },
-                "status": {
+                "games": {
+                    "type": "integer"
+                },
+                "name": {
                     "type": "string"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "rating": {
+                    "type": "number"
+                },
+                "wins": {
+                    "type": "integer"
                 }
             }
         },
-        "models.Game": {
+        "models.LobbyGame": {
             "type": "object",
             "properties": {
+                "capacity": {
+                    "type": "integer"
+                },
                 "id": {
                     "type": "string"
                 },
+                "open_seats": {
+                    "type": "integer"
+                },
                 "players": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "$ref": "#/definitions/models.PlayerInfo"
-                    }
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
                 },
                 "status": {
                     "$ref": "#/definitions/models.GameStatus"
+                }
+            }
+        },
+        "models.LobbyResponse": {
+            "type": "object",
+            "properties": {
+                "games": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LobbyGame"
+                    }
                 },
-                "turn": {
-                    "type": "string"
-                },
-                "visibility": {
-                    "type": "boolean"
-                },
-                "winner": {
+                "next_cursor": {
                     "type": "string"
                 }
             }
         },
-        "models.GameStatus": {
+        "models.MessageType": {
             "type": "string",
             "enum": [
-                "PENDING",
-                "IN_PROGRESS",
-                "END"
+                "state",
+                "player_joined",
+                "player_ready",
+                "game_started",
+                "strike_result",
+                "turn_change",
+                "game_over",
+                "turn_skipped",
+                "player_forfeited"
             ],
             "x-enum-varnames": [
-                "GameStatusPending",
-                "GameStatusInProgress",
-                "GameStatusEnd"
+                "MessageState",
+                "MessagePlayerJoined",
+                "MessagePlayerReady",
+                "MessageGameStarted",
+                "MessageStrikeResult",
+                "MessageTurnChange",
+                "MessageGameOver",
+                "MessageTurnSkipped",
+                "MessageForfeit"
+            ]
+        },
+        "models.Orientation": {
+            "type": "string",
+            "enum": [
+                "horizontal",
+                "vertical"
+            ],
+            "x-enum-varnames": [
+                "OrientationHorizontal",
+                "OrientationVertical"
             ]
         },
         "models.Plant": {
@@ -681,8 +2070,71 @@ const docTemplate = `{
                         "type": "string"
                     }
                 },
+                "mirrored": {
+                    "type": "boolean"
+                },
+                "orientation": {
+                    "$ref": "#/definitions/models.Orientation"
+                },
+                "rotation": {
+                    "description": "Rotation (clockwise, in degrees) and Mirrored tell how the footprint of\nplants with their own shape is laid",
+                    "type": "integer"
+                },
+                "type": {
+                    "$ref": "#/definitions/models.PlantType"
+                }
+            }
+        },
+        "models.PlantCatalog": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "plants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PlantSpec"
+                    }
+                }
+            }
+        },
+        "models.PlantSpec": {
+            "type": "object",
+            "properties": {
+                "capacity": {
+                    "type": "integer"
+                },
+                "cells": {
+                    "description": "Cells are the {row, column} offsets of the cells of non-rectangular\nplants; Width and Height are then the size of their bounding box",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "count": {
+                    "description": "Count is the most plants of this type a board can have (0 for no limit)",
+                    "type": "integer"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "mirror": {
+                    "description": "Mirror allows placing the plant mirrored as well as rotated",
+                    "type": "boolean"
+                },
+                "symbol": {
+                    "description": "Symbol draws the plant on ASCII maps, the first letter of the type by\ndefault",
+                    "type": "string"
+                },
                 "type": {
                     "$ref": "#/definitions/models.PlantType"
+                },
+                "width": {
+                    "type": "integer"
                 }
             }
         },
@@ -704,15 +2156,37 @@ const docTemplate = `{
         "models.PlayerInfo": {
             "type": "object",
             "properties": {
+                "account": {
+                    "description": "Account is set when the player joined with a registered account",
+                    "type": "boolean"
+                },
                 "board": {
                     "$ref": "#/definitions/models.Board"
                 },
                 "capacity": {
                     "type": "integer"
                 },
+                "eliminated": {
+                    "description": "Eliminated is set when the player is knocked out of an elimination\ngame. Place is the final place of a player that is out of the game.",
+                    "type": "boolean"
+                },
+                "forfeited": {
+                    "type": "boolean"
+                },
+                "place": {
+                    "type": "integer"
+                },
                 "ready": {
                     "type": "boolean"
                 },
+                "team": {
+                    "description": "Team is the team of the player in team games (1 or 2)",
+                    "type": "integer"
+                },
+                "timeouts": {
+                    "description": "Timeouts counts the turns in a row the player let expire; after too\nmany the player forfeits and is out of the game",
+                    "type": "integer"
+                },
                 "token": {
                     "type": "string"
                 },
@@ -721,6 +2195,39 @@ const docTemplate = `{
                 }
             }
         },
+        "models.QueueStatus": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "ExpiresAt is only set for signed tokens",
+                    "type": "string"
+                },
+                "game_id": {
+                    "type": "string"
+                },
+                "player": {
+                    "type": "string"
+                },
+                "players": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "ticket": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "waiting": {
+                    "type": "integer"
+                }
+            }
+        },
         "models.ReadyResponse": {
             "type": "object",
             "properties": {
@@ -729,6 +2236,133 @@ const docTemplate = `{
                 }
             }
         },
+        "models.RegisterResponse": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ReplayMove": {
+            "type": "object",
+            "properties": {
+                "attacker": {
+                    "type": "string"
+                },
+                "capacity": {
+                    "type": "integer"
+                },
+                "coordinate": {
+                    "type": "string"
+                },
+                "move": {
+                    "type": "integer"
+                },
+                "result": {
+                    "type": "string"
+                },
+                "target": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ReplayStep": {
+            "type": "object",
+            "properties": {
+                "boards": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.Board"
+                    }
+                },
+                "move": {
+                    "$ref": "#/definitions/models.ReplayMove"
+                },
+                "moves": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.GameStatus"
+                },
+                "step": {
+                    "type": "integer"
+                },
+                "turn": {
+                    "type": "string"
+                },
+                "winner": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SpectateResponse": {
+            "type": "object",
+            "properties": {
+                "delay": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "mode": {
+                    "$ref": "#/definitions/models.SpectatorMode"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SpectatorMode": {
+            "type": "string",
+            "enum": [
+                "blind",
+                "full",
+                "delayed"
+            ],
+            "x-enum-varnames": [
+                "SpectatorBlind",
+                "SpectatorFull",
+                "SpectatorDelayed"
+            ]
+        },
+        "models.SpectatorView": {
+            "type": "object",
+            "properties": {
+                "delay": {
+                    "type": "integer"
+                },
+                "game": {
+                    "$ref": "#/definitions/models.Game"
+                },
+                "mode": {
+                    "$ref": "#/definitions/models.SpectatorMode"
+                },
+                "moves": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.StrikeInfo": {
+            "type": "object",
+            "properties": {
+                "attacker": {
+                    "type": "string"
+                },
+                "coordinate": {
+                    "type": "string"
+                },
+                "result": {
+                    "type": "string"
+                }
+            }
+        },
         "models.StrikeResponse": {
             "type": "object",
             "properties": {
@@ -739,6 +2373,42 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "models.WaitResponse": {
+            "type": "object",
+            "properties": {
+                "game": {
+                    "$ref": "#/definitions/models.Game"
+                },
+                "last_strike": {
+                    "$ref": "#/definitions/models.StrikeInfo"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WeatherConditions": {
+            "type": "object",
+            "properties": {
+                "round": {
+                    "type": "integer"
+                },
+                "sun": {
+                    "type": "integer"
+                },
+                "wind": {
+                    "type": "integer"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "BearerAuth": {
+            "description": "Player token as \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
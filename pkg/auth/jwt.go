@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Errors returned by Verify
+var (
+	ErrInvalidToken = errors.New("INVALID_TOKEN")
+	ErrExpiredToken = errors.New("TOKEN_EXPIRED")
+)
+
+// header is the only JWT header issued and accepted
+var header = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Roles of the token holders
+const (
+	RolePlayer    = "player"
+	RoleSpectator = "spectator"
+)
+
+// Claims identifies a player or a spectator of a game
+type Claims struct {
+	GameID    string `json:"gid"`
+	Player    string `json:"sub,omitempty"`
+	Role      string `json:"role"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Signer issues and verifies HMAC-SHA256 signed JWTs (HS256)
+type Signer struct {
+	key []byte
+	ttl time.Duration
+}
+
+// NewSigner creates a signer with the given secret key. Tokens are valid
+// for ttl after being issued.
+func NewSigner(key []byte, ttl time.Duration) *Signer {
+	return &Signer{key: key, ttl: ttl}
+}
+
+// Sign issues a token for a player of a game, or for a spectator when the
+// player is empty
+func (s *Signer) Sign(gameID string, player string) (string, time.Time, error) {
+	role := RolePlayer
+	if player == "" {
+		role = RoleSpectator
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+
+	payload, err := json.Marshal(Claims{
+		GameID:    gameID,
+		Player:    player,
+		Role:      role,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + s.signature(unsigned), expiresAt, nil
+}
+
+// Verify checks the signature and expiry of a token and returns its claims
+func (s *Signer) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != header {
+		return nil, ErrInvalidToken
+	}
+
+	// Check the signature before looking at the claims
+	expected := s.signature(parts[0] + "." + parts[1])
+	if !hmac.Equal([]byte(parts[2]), []byte(expected)) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrExpiredToken
+	}
+
+	return &claims, nil
+}
+
+// signature signs the header and payload of a token
+func (s *Signer) signature(unsigned string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
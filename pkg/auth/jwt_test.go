@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// forge builds a token with the given header and claims, signed by signer
+func forge(t *testing.T, signer *Signer, rawHeader string, claims Claims) string {
+	t.Helper()
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(rawHeader)) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + signer.signature(unsigned)
+}
+
+func TestSignVerify(t *testing.T) {
+	signer := NewSigner([]byte(strings.Repeat("k", 32)), time.Hour)
+
+	token, expiresAt, err := signer.Sign("g1", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := signer.Verify(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.GameID != "g1" || claims.Player != "alice" || claims.Role != RolePlayer || claims.ExpiresAt != expiresAt.Unix() {
+		t.Fatalf("unexpected player claims %+v", claims)
+	}
+
+	token, _, err = signer.Sign("g1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err = signer.Verify(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.GameID != "g1" || claims.Player != "" || claims.Role != RoleSpectator {
+		t.Fatalf("unexpected spectator claims %+v", claims)
+	}
+}
+
+func TestVerifyRejectsBadTokens(t *testing.T) {
+	signer := NewSigner([]byte(strings.Repeat("k", 32)), time.Hour)
+	other := NewSigner([]byte(strings.Repeat("o", 32)), time.Hour)
+	expired := NewSigner([]byte(strings.Repeat("k", 32)), -time.Minute)
+
+	valid, _, err := signer.Sign("g1", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(valid, ".")
+	claims := Claims{GameID: "g1", Player: "alice", Role: RolePlayer, ExpiresAt: time.Now().Add(time.Hour).Unix()}
+
+	// Swap the payload for another player's, keeping the signature
+	bob := claims
+	bob.Player = "bob"
+	bobPayload, err := json.Marshal(bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Flip the last character of the signature
+	signature := []byte(parts[2])
+	if signature[len(signature)-1] == 'A' {
+		signature[len(signature)-1] = 'B'
+	} else {
+		signature[len(signature)-1] = 'A'
+	}
+
+	expiredToken, _, err := expired.Sign("g1", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		expected error
+	}{
+		{name: "empty", token: "", expected: ErrInvalidToken},
+		{name: "not a jwt", token: "not-a-token", expected: ErrInvalidToken},
+		{name: "tampered signature", token: parts[0] + "." + parts[1] + "." + string(signature), expected: ErrInvalidToken},
+		{name: "missing signature", token: parts[0] + "." + parts[1] + ".", expected: ErrInvalidToken},
+		{name: "tampered payload", token: parts[0] + "." + base64.RawURLEncoding.EncodeToString(bobPayload) + "." + parts[2], expected: ErrInvalidToken},
+		{name: "other secret", token: forge(t, other, `{"alg":"HS256","typ":"JWT"}`, claims), expected: ErrInvalidToken},
+		{name: "alg none", token: forge(t, signer, `{"alg":"none","typ":"JWT"}`, claims), expected: ErrInvalidToken},
+		{name: "alg none unsigned", token: base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + parts[1] + ".", expected: ErrInvalidToken},
+		{name: "alg HS512", token: forge(t, signer, `{"alg":"HS512","typ":"JWT"}`, claims), expected: ErrInvalidToken},
+		{name: "expired", token: expiredToken, expected: ErrExpiredToken},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := signer.Verify(test.token); !errors.Is(err, test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package bot
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+// maxPlacementAttempts is the number of boards tried before giving up
+const maxPlacementAttempts = 100
+
+// RandomBoard builds a board with randomly chosen and placed plants of the
+// catalog whose total capacity is at least capacity and, when possible, at
+// most 10% over it
+func RandomBoard(rng *rand.Rand, size int, capacity int, catalog *models.PlantCatalog) (*models.Board, error) {
+	maxCapacity := capacity + capacity/10
+
+	for attempt := 0; attempt < maxPlacementAttempts; attempt++ {
+		// Pick plants until the capacity is reached
+		var plants []models.PlantSpec
+		counts := make(map[models.PlantType]int)
+		total := 0
+		for total < capacity {
+			// Prefer plants that do not overshoot the maximum, falling back
+			// to the smallest plant left
+			var candidates []models.PlantSpec
+			var smallest *models.PlantSpec
+			for i, plant := range catalog.Plants {
+				if plant.Count > 0 && counts[plant.Type] >= plant.Count {
+					continue
+				}
+				if total+plant.Capacity <= maxCapacity {
+					candidates = append(candidates, plant)
+				}
+				if smallest == nil || plant.Capacity < smallest.Capacity {
+					smallest = &catalog.Plants[i]
+				}
+			}
+			if len(candidates) == 0 && smallest != nil {
+				candidates = []models.PlantSpec{*smallest}
+			}
+			if len(candidates) == 0 {
+				return nil, errors.New("not enough plants in the catalog to reach the capacity")
+			}
+
+			plant := candidates[rng.Intn(len(candidates))]
+			plants = append(plants, plant)
+			counts[plant.Type]++
+			total += plant.Capacity
+		}
+
+		// Place them, biggest first as they are the hardest to fit
+		sort.SliceStable(plants, func(i, j int) bool {
+			return len(plants[i].Footprint()) > len(plants[j].Footprint())
+		})
+		if board, ok := placePlants(rng, size, plants); ok {
+			return board, nil
+		}
+	}
+
+	return nil, errors.New("could not place plants on the board")
+}
+
+// placePlants places the plants at random free positions and orientations
+func placePlants(rng *rand.Rand, size int, plants []models.PlantSpec) (*models.Board, bool) {
+	occupied := make([][]bool, size)
+	for i := range occupied {
+		occupied[i] = make([]bool, size)
+	}
+
+	board := &models.Board{}
+	for _, spec := range plants {
+		// Collect every free position for this plant, in every shape
+		type position struct {
+			y, x  int
+			shape models.PlantShape
+		}
+		var positions []position
+		for _, shape := range spec.Shapes() {
+			for y := 0; y < size; y++ {
+				for x := 0; x < size; x++ {
+					if fits(occupied, size, y, x, shape.Cells) {
+						positions = append(positions, position{y, x, shape})
+					}
+				}
+			}
+		}
+		if len(positions) == 0 {
+			return nil, false
+		}
+
+		chosen := positions[rng.Intn(len(positions))]
+		plant := models.Plant{Type: spec.Type}
+		for _, cell := range chosen.shape.Cells {
+			y, x := chosen.y+cell[0], chosen.x+cell[1]
+			occupied[y][x] = true
+			plant.Coordinates = append(plant.Coordinates, models.FormatCoordinate(y, x))
+		}
+		board.Plants = append(board.Plants, plant)
+	}
+
+	return board, true
+}
+
+// fits checks that the cells of a shape at (y, x) are on the board and free
+func fits(occupied [][]bool, size int, y int, x int, cells [][2]int) bool {
+	for _, cell := range cells {
+		cy, cx := y+cell[0], x+cell[1]
+		if cy >= size || cx >= size || occupied[cy][cx] {
+			return false
+		}
+	}
+	return true
+}
+
+// knownCells returns a grid marking the cells already hit or missed
+func knownCells(board *models.Board, size int) [][]bool {
+	known := make([][]bool, size)
+	for i := range known {
+		known[i] = make([]bool, size)
+	}
+
+	for _, coords := range [][]string{board.Hits, board.Misses} {
+		for _, coord := range coords {
+			y, x, err := models.ParseCoordinate(coord)
+			if err != nil || y < 0 || y >= size || x < 0 || x >= size {
+				continue
+			}
+			known[y][x] = true
+		}
+	}
+
+	return known
+}
+
+// unknownCells lists the coordinates that have not been struck yet
+func unknownCells(board *models.Board, size int) []string {
+	known := knownCells(board, size)
+
+	var cells []string
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if !known[y][x] {
+				cells = append(cells, models.FormatCoordinate(y, x))
+			}
+		}
+	}
+
+	return cells
+}
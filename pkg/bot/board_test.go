@@ -0,0 +1,38 @@
+package bot
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/xorduna/energywar/pkg/game"
+	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
+)
+
+func TestRandomBoardIsValid(t *testing.T) {
+	gm := game.NewGameManager(store.NewMemoryStore())
+	for _, catalog := range models.Catalogs {
+		for size := 5; size <= 20; size++ {
+			for _, capacity := range []int{100, 1000, 2000} {
+				for seed := int64(1); seed <= 5; seed++ {
+					board, err := RandomBoard(rand.New(rand.NewSource(seed)), size, capacity, catalog)
+					if err != nil {
+						t.Fatalf("%s catalog, size %d, capacity %d, seed %d: %v", catalog.Name, size, capacity, seed, err)
+					}
+
+					// The game checks the board like the one of any player
+					gameObj, err := gm.CreateGame(models.GameOptions{Size: size, Capacity: capacity, Catalog: catalog.Name})
+					if err != nil {
+						t.Fatal(err)
+					}
+					if _, err := gm.JoinGame(gameObj.ID, "bot", 0); err != nil {
+						t.Fatal(err)
+					}
+					if _, err := gm.SetBoard(gameObj.ID, "bot", board); err != nil {
+						t.Fatalf("%s catalog, size %d, capacity %d, seed %d: %v", catalog.Name, size, capacity, seed, err)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/xorduna/energywar/pkg/game"
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+// Timing of the bot loop
+const (
+	// waitInterval is how long a bot waits for its turn before checking the
+	// game again, which also catches changes made by other server replicas
+	waitInterval = 5 * time.Second
+	// maxIdle is how long a bot waits without playing before leaving the game
+	maxIdle = time.Hour
+	// thinkTime is the pause before each strike so humans can follow the game
+	thinkTime = 500 * time.Millisecond
+	// joinAttempts is how many names a bot tries when players keep taking
+	// the one it picked
+	joinAttempts = game.MaxPlayers
+)
+
+// Bot plays a game on behalf of the server
+type Bot struct {
+	Name     string
+	Level    Level
+	GameID   string
+	Strategy Strategy
+
+	gm *game.GameManager
+}
+
+// New creates a bot that plays as name in a game with the given strategy.
+// Call Join to add it to the game.
+func New(gm *game.GameManager, gameID string, name string, strategy Strategy) *Bot {
+	return &Bot{
+		Name:     name,
+		GameID:   gameID,
+		Strategy: strategy,
+		gm:       gm,
+	}
+}
+
+// Join adds a bot of the given level to a game: it joins, places a valid
+// board and gets ready. Call Play to have it take its turns.
+func Join(gm *game.GameManager, gameID string, level Level) (*Bot, error) {
+	strategy, err := NewStrategy(level, rand.New(rand.NewSource(time.Now().UnixNano())))
+	if err != nil {
+		return nil, err
+	}
+
+	// Pick a name that is not taken yet, and another one if somebody else
+	// joins with it first
+	for attempt := 1; ; attempt++ {
+		gameObj, err := gm.GetGame(gameID)
+		if err != nil {
+			return nil, err
+		}
+
+		b := New(gm, gameID, freeName(gameObj, level), strategy)
+		b.Level = level
+		err = b.Join()
+		if errors.Is(err, game.ErrPlayerExists) && attempt < joinAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return b, nil
+	}
+}
+
+// freeName returns the first bot name of the level no player of the game has
+func freeName(gameObj *models.Game, level Level) string {
+	name := fmt.Sprintf("bot-%s", level)
+	for i := 2; ; i++ {
+		if _, exists := gameObj.Players[name]; !exists {
+			return name
+		}
+		name = fmt.Sprintf("bot-%s-%d", level, i)
+	}
+}
+
+// Join joins the game, places the board chosen by the strategy and gets ready
+func (b *Bot) Join() error {
+	// Prepare the board before joining so a failure does not leave
+	// a player that never gets ready
+	board, err := b.placeBoard()
+	if err != nil {
+		return err
+	}
+
+	if _, err := b.gm.JoinGame(b.GameID, b.Name, 0); err != nil {
+		return err
+	}
+
+	return b.setBoard(board)
+}
+
+// Ready places the board chosen by the strategy and gets ready, for a bot
+// that already joined the game
+func (b *Bot) Ready() error {
+	board, err := b.placeBoard()
+	if err != nil {
+		return err
+	}
+
+	return b.setBoard(board)
+}
+
+// placeBoard asks the strategy for a board that fits the game
+func (b *Bot) placeBoard() (*models.Board, error) {
+	gameObj, err := b.gm.GetGame(b.GameID)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Strategy.PlaceBoard(gameObj.Size, gameObj.Capacity, gameObj.PlantCatalog())
+}
+
+// setBoard sets the board of the bot and gets ready
+func (b *Bot) setBoard(board *models.Board) error {
+	if _, err := b.gm.SetBoard(b.GameID, b.Name, board); err != nil {
+		return err
+	}
+
+	return b.gm.SetPlayerReady(b.GameID, b.Name)
+}
+
+// Play strikes every time it is the bot's turn until the game ends, the game
+// is idle for too long or ctx is done
+func (b *Bot) Play(ctx context.Context) {
+	lastMove := time.Now()
+	for time.Since(lastMove) < maxIdle {
+		waitCtx, cancel := context.WithTimeout(ctx, waitInterval)
+		gameObj, err := b.gm.WaitForTurn(waitCtx, b.GameID, b.Name)
+		cancel()
+
+		if ctx.Err() != nil {
+			return
+		}
+		if gameObj == nil {
+			log.Printf("bot %s left game %s: %v", b.Name, b.GameID, err)
+			return
+		}
+		if gameObj.Status == models.GameStatusEnd {
+			return
+		}
+		if err != nil {
+			// Not our turn yet
+			continue
+		}
+
+		time.Sleep(thinkTime)
+		if err := b.Strike(gameObj); err != nil {
+			log.Printf("bot %s failed to strike in game %s: %v", b.Name, b.GameID, err)
+			continue
+		}
+		lastMove = time.Now()
+	}
+}
+
+// Strike chooses a target with the bot's strategy and strikes it
+func (b *Bot) Strike(gameObj *models.Game) error {
+	// Collect the blind boards of the opponents
+	team := gameObj.Players[b.Name].Team
+	boards := make(map[string]*models.Board)
+	for name, info := range gameObj.Players {
+		if name == b.Name || info.Board == nil || !info.InGame() {
+			continue
+		}
+		// Teammates are not opponents
+		if team != 0 && info.Team == team {
+			continue
+		}
+		boards[name] = info.Board.GenerateBlindBoard()
+	}
+	if len(boards) == 0 {
+		return errors.New("no opponent to strike")
+	}
+
+	target, coord, err := b.Strategy.ChooseTarget(gameObj.Size, gameObj.PlantCatalog(), boards)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.gm.Strike(b.GameID, b.Name, target, coord)
+	return err
+}
@@ -0,0 +1,69 @@
+package bot
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xorduna/energywar/pkg/game"
+	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
+)
+
+// slowStore takes a while to read games, so bots added at once all see the
+// game before any of them joins
+type slowStore struct {
+	*store.MemoryStore
+}
+
+func (s slowStore) Get(id string) (*models.Game, error) {
+	time.Sleep(10 * time.Millisecond)
+	return s.MemoryStore.Get(id)
+}
+
+func TestConcurrentBotsGetTheirOwnNames(t *testing.T) {
+	for run := 0; run < 5; run++ {
+		gm := game.NewGameManager(slowStore{store.NewMemoryStore()})
+		gameObj, err := gm.CreateGame(models.GameOptions{Size: 10, Capacity: 1000})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// alice is not ready, so the game waits for the bots of the same
+		// level added at once to fill it
+		if _, err := gm.JoinGame(gameObj.ID, "alice", 0); err != nil {
+			t.Fatal(err)
+		}
+		var wg sync.WaitGroup
+		errs := make(chan error, game.MaxPlayers-1)
+		for i := 1; i < game.MaxPlayers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := Join(gm, gameObj.ID, LevelEasy)
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		gameObj, err = gm.GetGame(gameObj.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ready := 0
+		for _, info := range gameObj.Players {
+			if info.Ready {
+				ready++
+			}
+		}
+		if len(gameObj.Players) != game.MaxPlayers || ready != game.MaxPlayers-1 {
+			t.Fatalf("expected %d ready bots, got %d players and %d ready", game.MaxPlayers-1, len(gameObj.Players), ready)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/xorduna/energywar/pkg/game"
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+// Level represents the difficulty of a bot
+type Level string
+
+const (
+	LevelEasy   Level = "easy"
+	LevelMedium Level = "medium"
+	LevelHard   Level = "hard"
+)
+
+// Strategy decides where a bot places its plants and where it strikes
+type Strategy interface {
+	// PlaceBoard returns a valid board for a game of the given size and
+	// capacity played with the plants of catalog
+	PlaceBoard(size int, capacity int, catalog *models.PlantCatalog) (*models.Board, error)
+	// ChooseTarget picks an opponent and a coordinate to strike given the
+	// opponents' blind boards
+	ChooseTarget(size int, catalog *models.PlantCatalog, boards map[string]*models.Board) (string, string, error)
+}
+
+// NewStrategy creates the strategy for a difficulty level.
+// All random decisions are taken from rng, so a seeded source gives
+// reproducible games.
+func NewStrategy(level Level, rng *rand.Rand) (Strategy, error) {
+	switch level {
+	case LevelEasy:
+		return &randomStrategy{rng: rng}, nil
+	case LevelMedium:
+		return &huntStrategy{randomStrategy{rng: rng}}, nil
+	case LevelHard:
+		return &densityStrategy{randomStrategy{rng: rng}}, nil
+	default:
+		return nil, fmt.Errorf("unknown bot level: %s", level)
+	}
+}
+
+// randomStrategy strikes random cells of random opponents
+type randomStrategy struct {
+	rng *rand.Rand
+}
+
+// PlaceBoard places random plants
+func (s *randomStrategy) PlaceBoard(size int, capacity int, catalog *models.PlantCatalog) (*models.Board, error) {
+	return RandomBoard(s.rng, size, capacity, catalog)
+}
+
+// ChooseTarget strikes a random unknown cell of a random opponent
+func (s *randomStrategy) ChooseTarget(size int, catalog *models.PlantCatalog, boards map[string]*models.Board) (string, string, error) {
+	targets := targetsWithCells(size, boards)
+	if len(targets) == 0 {
+		return "", "", errors.New("no cell left to strike")
+	}
+
+	target := targets[s.rng.Intn(len(targets))]
+	cells := unknownCells(boards[target], size)
+
+	return target, cells[s.rng.Intn(len(cells))], nil
+}
+
+// huntStrategy strikes around previous hits first (target mode) and falls
+// back to random strikes (hunt mode). Hits on plants still standing, in
+// partial damage games, come before the others.
+type huntStrategy struct {
+	randomStrategy
+}
+
+// ChooseTarget strikes an unknown cell next to a hit, or a random one
+func (s *huntStrategy) ChooseTarget(size int, catalog *models.PlantCatalog, boards map[string]*models.Board) (string, string, error) {
+	targets := targetsWithCells(size, boards)
+	for _, target := range targets {
+		if candidates := neighbours(boards[target], size, boards[target].OpenHits()); len(candidates) > 0 {
+			return target, candidates[s.rng.Intn(len(candidates))], nil
+		}
+	}
+	for _, target := range targets {
+		if candidates := neighbours(boards[target], size, boards[target].Hits); len(candidates) > 0 {
+			return target, candidates[s.rng.Intn(len(candidates))], nil
+		}
+	}
+
+	return s.randomStrategy.ChooseTarget(size, catalog, boards)
+}
+
+// neighbours lists the unknown cells next to the given cells of a board
+func neighbours(board *models.Board, size int, cells []string) []string {
+	known := knownCells(board, size)
+
+	var candidates []string
+	for _, hit := range cells {
+		y, x, err := models.ParseCoordinate(hit)
+		if err != nil {
+			continue
+		}
+		for _, d := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+			ny, nx := y+d[0], x+d[1]
+			if ny < 0 || ny >= size || nx < 0 || nx >= size || known[ny][nx] {
+				continue
+			}
+			candidates = append(candidates, models.FormatCoordinate(ny, nx))
+		}
+	}
+
+	return candidates
+}
+
+// densityStrategy strikes the cell covered by the most possible placements of
+// the plants that can still be standing, on the opponent closest to losing
+type densityStrategy struct {
+	randomStrategy
+}
+
+// ChooseTarget strikes the most likely cell of the weakest opponent
+func (s *densityStrategy) ChooseTarget(size int, catalog *models.PlantCatalog, boards map[string]*models.Board) (string, string, error) {
+	targets := targetsWithCells(size, boards)
+	if len(targets) == 0 {
+		return "", "", errors.New("no cell left to strike")
+	}
+
+	// Go for the opponent with the least capacity left before being knocked out
+	sort.SliceStable(targets, func(i, j int) bool {
+		return margin(boards[targets[i]]) < margin(boards[targets[j]])
+	})
+	target := targets[0]
+
+	// Pick randomly among the cells with the highest density
+	grid := game.Heatmap(boards[target], size, catalog).Cells
+	best := 0
+	var candidates []string
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			switch {
+			case grid[y][x] > best:
+				best = grid[y][x]
+				candidates = []string{models.FormatCoordinate(y, x)}
+			case grid[y][x] == best && best > 0:
+				candidates = append(candidates, models.FormatCoordinate(y, x))
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		// No plant can fit anywhere, strike any unknown cell
+		cells := unknownCells(boards[target], size)
+		return target, cells[s.rng.Intn(len(cells))], nil
+	}
+
+	return target, candidates[s.rng.Intn(len(candidates))], nil
+}
+
+// margin returns the capacity an opponent can still lose before being knocked out
+func margin(board *models.Board) int {
+	return board.Capacity - int(float64(board.TotalCapacity)*0.1)
+}
+
+// targetsWithCells lists, in name order, the opponents with cells left to strike
+func targetsWithCells(size int, boards map[string]*models.Board) []string {
+	var targets []string
+	for name, board := range boards {
+		if len(unknownCells(board, size)) > 0 {
+			targets = append(targets, name)
+		}
+	}
+	sort.Strings(targets)
+
+	return targets
+}
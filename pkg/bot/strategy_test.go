@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+func TestStrategiesStrikeUnknownCells(t *testing.T) {
+	const size = 6
+	for _, level := range []Level{LevelEasy, LevelMedium, LevelHard} {
+		strategy, err := NewStrategy(level, rand.New(rand.NewSource(1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Strike until no cell is left, turning every other strike into a hit
+		boards := map[string]*models.Board{
+			"alice": {TotalCapacity: 1000, Capacity: 1000},
+			"bob":   {TotalCapacity: 1000, Capacity: 1000},
+		}
+		for strikes := 0; strikes < 2*size*size; strikes++ {
+			target, coord, err := strategy.ChooseTarget(size, models.DefaultCatalog, boards)
+			if err != nil {
+				t.Fatalf("%s strike %d: %v", level, strikes+1, err)
+			}
+			board, exists := boards[target]
+			if !exists {
+				t.Fatalf("%s struck unknown opponent %s", level, target)
+			}
+			if err := models.ValidateCoordinate(coord, size); err != nil {
+				t.Fatalf("%s struck %s off the board: %v", level, coord, err)
+			}
+			if slices.Contains(board.Hits, coord) || slices.Contains(board.Misses, coord) {
+				t.Fatalf("%s struck %s of %s twice", level, coord, target)
+			}
+
+			if strikes%2 == 0 {
+				board.Hits = append(board.Hits, coord)
+			} else {
+				board.Misses = append(board.Misses, coord)
+			}
+		}
+
+		if _, _, err := strategy.ChooseTarget(size, models.DefaultCatalog, boards); err == nil {
+			t.Fatalf("%s expected an error once every cell was struck", level)
+		}
+	}
+}
+
+func TestMediumStrategyFollowsHits(t *testing.T) {
+	const size = 6
+	for seed := int64(1); seed <= 20; seed++ {
+		strategy, err := NewStrategy(LevelMedium, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The hits on alice are on a destroyed plant, the one on bob on a
+		// plant still standing
+		boards := map[string]*models.Board{
+			"alice": {Hits: []string{"A1", "A2"}, Destroyed: []string{"A1", "A2"}, TotalCapacity: 1000, Capacity: 900},
+			"bob":   {Hits: []string{"C3"}, Misses: []string{"C4"}, TotalCapacity: 1000, Capacity: 1000},
+		}
+		target, coord, err := strategy.ChooseTarget(size, models.DefaultCatalog, boards)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target != "bob" || !slices.Contains([]string{"B3", "D3", "C2"}, coord) {
+			t.Fatalf("seed %d: expected a strike next to C3 of bob, got %s of %s", seed, coord, target)
+		}
+	}
+}
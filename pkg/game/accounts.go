@@ -0,0 +1,115 @@
+package game
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
+)
+
+// Account settings
+const (
+	// InitialRating is the rating of a new account
+	InitialRating = 1500
+	// maxNameLength is the longest account name allowed
+	maxNameLength = 32
+	// botPrefix starts the names of the bots, which cannot be registered
+	botPrefix = "bot-"
+)
+
+// Register creates an account and returns its API key. Only a hash of the key
+// is stored, so it cannot be recovered if lost.
+func (gm *GameManager) Register(name string) (string, error) {
+	if name == "" || len(name) > maxNameLength {
+		return "", errors.New("INVALID_NAME")
+	}
+	if strings.HasPrefix(name, botPrefix) {
+		return "", errors.New("NAME_RESERVED")
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	key := hex.EncodeToString(b)
+
+	err := gm.store.CreateAccount(&models.Account{
+		Name:      name,
+		KeyHash:   hashKey(key),
+		Rating:    InitialRating,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// Authenticate checks the API key of an account
+func (gm *GameManager) Authenticate(name string, key string) error {
+	account, err := gm.store.GetAccount(name)
+	if err != nil {
+		if errors.Is(err, store.ErrAccountNotFound) {
+			return errors.New("INVALID_KEY")
+		}
+		return err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashKey(key)), []byte(account.KeyHash)) != 1 {
+		return errors.New("INVALID_KEY")
+	}
+
+	return nil
+}
+
+// JoinAccount allows a registered player to join a game with its API key,
+// in the given team for team games. The game counts towards the account's
+// rating.
+func (gm *GameManager) JoinAccount(gameID string, name string, key string, team int) (string, error) {
+	if err := gm.Authenticate(name, key); err != nil {
+		return "", err
+	}
+
+	return gm.join(gameID, name, true, team)
+}
+
+// JoinAuthenticated adds a registered player to a game like JoinAccount, for
+// callers that already checked the API key with Authenticate (such as the
+// matchmaking queue, which places players in games after they queued)
+func (gm *GameManager) JoinAuthenticated(gameID string, name string, team int) (string, error) {
+	return gm.join(gameID, name, true, team)
+}
+
+// GetAccount retrieves an account by name
+func (gm *GameManager) GetAccount(name string) (*models.Account, error) {
+	return gm.store.GetAccount(name)
+}
+
+// Leaderboard returns the best rated accounts
+func (gm *GameManager) Leaderboard(limit int) ([]models.LeaderboardEntry, error) {
+	accounts, err := gm.store.ListAccounts(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.LeaderboardEntry, len(accounts))
+	for i, account := range accounts {
+		entries[i] = models.LeaderboardEntry{Rank: i + 1, Account: *account}
+	}
+
+	return entries, nil
+}
+
+// hashKey hashes an API key. Keys are long random strings, so a plain
+// SHA-256 is enough.
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
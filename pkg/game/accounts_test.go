@@ -0,0 +1,102 @@
+package game
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xorduna/energywar/pkg/store"
+)
+
+func TestRegister(t *testing.T) {
+	gm := NewGameManager(store.NewMemoryStore())
+	if _, err := gm.Register("alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		err  string
+	}{
+		{name: "bob"},
+		{name: strings.Repeat("a", maxNameLength)},
+		{name: "", err: "INVALID_NAME"},
+		{name: strings.Repeat("a", maxNameLength+1), err: "INVALID_NAME"},
+		{name: "bot-alice", err: "NAME_RESERVED"},
+		{name: botPrefix, err: "NAME_RESERVED"},
+		{name: "alice", err: store.ErrAccountExists.Error()},
+	}
+	for _, test := range tests {
+		key, err := gm.Register(test.name)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Fatalf("registering %q: expected %s, got %v", test.name, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("registering %q: %v", test.name, err)
+		}
+
+		// Only the hash of the key is stored
+		account, err := gm.GetAccount(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if account.KeyHash == key || account.KeyHash != hashKey(key) {
+			t.Fatalf("expected the hash of the key of %q to be stored, got %s", test.name, account.KeyHash)
+		}
+		if account.Rating != InitialRating {
+			t.Fatalf("expected %q to start at %d, got %v", test.name, InitialRating, account.Rating)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	gm := NewGameManager(store.NewMemoryStore())
+	aliceKey, err := gm.Register("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobKey, err := gm.Register("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		key  string
+		err  string
+	}{
+		{name: "alice", key: aliceKey},
+		{name: "bob", key: bobKey},
+		{name: "alice", key: bobKey, err: "INVALID_KEY"},
+		{name: "alice", key: aliceKey + "0", err: "INVALID_KEY"},
+		{name: "alice", key: "", err: "INVALID_KEY"},
+		{name: "alice", key: hashKey(aliceKey), err: "INVALID_KEY"},
+		{name: "carol", key: aliceKey, err: "INVALID_KEY"},
+	}
+	for _, test := range tests {
+		err := gm.Authenticate(test.name, test.key)
+		if test.err == "" {
+			if err != nil {
+				t.Fatalf("authenticating %s: %v", test.name, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != test.err {
+			t.Fatalf("authenticating %s with %q: expected %s, got %v", test.name, test.key, test.err, err)
+		}
+	}
+
+	// A bad key does not join the game either
+	game, err := gm.CreateGame(testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gm.JoinAccount(game.ID, "alice", bobKey, 0); err == nil || err.Error() != "INVALID_KEY" {
+		t.Fatalf("expected INVALID_KEY joining with a bad key, got %v", err)
+	}
+	if _, err := gm.JoinAccount(game.ID, "alice", aliceKey, 0); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,64 @@
+package game
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/ghodss/yaml"
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+// ParseCatalog parses a plant catalog written in YAML or JSON
+func ParseCatalog(data []byte) (*models.PlantCatalog, error) {
+	var catalog models.PlantCatalog
+	if err := yaml.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("invalid plant catalog: %v", err)
+	}
+	// The size of plants defined by their cells can be left out
+	for i, plant := range catalog.Plants {
+		if len(plant.Cells) > 0 && plant.Width == 0 && plant.Height == 0 {
+			catalog.Plants[i].Width, catalog.Plants[i].Height = plant.BoundingBox()
+		}
+	}
+	if err := catalog.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &catalog, nil
+}
+
+// LoadCatalog reads a plant catalog from a YAML or JSON file
+func LoadCatalog(path string) (*models.PlantCatalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseCatalog(data)
+}
+
+// AddCatalog makes a plant catalog available to new games, replacing any
+// catalog with the same name. Catalogs must be added before the manager is
+// used.
+func (gm *GameManager) AddCatalog(catalog *models.PlantCatalog) error {
+	if err := catalog.Validate(); err != nil {
+		return err
+	}
+
+	gm.catalogs[catalog.Name] = catalog
+	return nil
+}
+
+// Catalogs lists the plant catalogs available to new games by name
+func (gm *GameManager) Catalogs() []*models.PlantCatalog {
+	catalogs := make([]*models.PlantCatalog, 0, len(gm.catalogs))
+	for _, catalog := range gm.catalogs {
+		catalogs = append(catalogs, catalog)
+	}
+	sort.Slice(catalogs, func(i, j int) bool {
+		return catalogs[i].Name < catalogs[j].Name
+	})
+
+	return catalogs
+}
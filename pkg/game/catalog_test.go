@@ -0,0 +1,104 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+func TestLoadCatalog(t *testing.T) {
+	tests := []struct {
+		file string
+		data string
+		err  string
+	}{
+		{
+			file: "islands.yaml",
+			data: `name: islands
+plants:
+  - type: NUCLEAR
+    capacity: 1000
+    width: 3
+    height: 3
+    count: 1
+  - type: HYDRO
+    capacity: 400
+    cells: [[0, 0], [1, 0], [2, 0], [2, 1]]
+    mirror: true
+    symbol: "Y"
+`,
+		},
+		{
+			file: "islands.json",
+			data: `{"name": "islands", "plants": [
+				{"type": "NUCLEAR", "capacity": 1000, "width": 3, "height": 3, "count": 1},
+				{"type": "HYDRO", "capacity": 400, "cells": [[0, 0], [1, 0], [2, 0], [2, 1]], "mirror": true, "symbol": "Y"}
+			]}`,
+		},
+		{
+			file: "duplicate.yaml",
+			data: `name: duplicate
+plants:
+  - {type: SOLAR, capacity: 25, width: 1, height: 1}
+  - {type: SOLAR, capacity: 50, width: 1, height: 1}
+`,
+			err: "defined twice",
+		},
+		{
+			file: "zero.json",
+			data: `{"name": "zero", "plants": [{"type": "SOLAR", "capacity": 0, "width": 1, "height": 1}]}`,
+			err:  "capacity of SOLAR",
+		},
+		{
+			file: "shape.yaml",
+			data: `name: shape
+plants:
+  - {type: HYDRO, capacity: 400, cells: [[0, 0], [2, 0]], symbol: "Y"}
+`,
+			err: "distinct and side by side",
+		},
+		{
+			file: "broken.yaml",
+			data: "name: [broken",
+			err:  "invalid plant catalog",
+		},
+	}
+
+	dir := t.TempDir()
+	for _, test := range tests {
+		path := filepath.Join(dir, test.file)
+		if err := os.WriteFile(path, []byte(test.data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		catalog, err := LoadCatalog(path)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("%s: expected an error about %q, got %v", test.file, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", test.file, err)
+		}
+
+		if catalog.Name != "islands" || len(catalog.Plants) != 2 {
+			t.Fatalf("%s: unexpected catalog %+v", test.file, catalog)
+		}
+		// The size of the plants defined by their cells is worked out
+		hydro, exists := catalog.Plant("HYDRO")
+		if !exists || hydro.Width != 2 || hydro.Height != 3 || !hydro.Mirror || catalog.Symbol("HYDRO") != "Y" {
+			t.Fatalf("%s: unexpected HYDRO plant %+v", test.file, hydro)
+		}
+		if nuclear, _ := catalog.Plant(models.PlantTypeNuclear); nuclear.Count != 1 || nuclear.Capacity != 1000 {
+			t.Fatalf("%s: unexpected NUCLEAR plant %+v", test.file, nuclear)
+		}
+	}
+
+	if _, err := LoadCatalog(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Fatal("expected an error loading a missing file")
+	}
+}
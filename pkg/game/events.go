@@ -0,0 +1,163 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+// newGame creates an empty game with the given options
+func newGame(id string, opts models.GameOptions) *models.Game {
+	return &models.Game{
+		ID:          id,
+		Status:      models.GameStatusPending,
+		Turn:        "",
+		Winner:      nil,
+		Players:     make(map[string]models.PlayerInfo),
+		Size:        opts.Size,
+		Capacity:    opts.Capacity,
+		Public:      opts.Public,
+		Training:    opts.Training,
+		Elimination: opts.Elimination,
+		Teams:       opts.Teams,
+
+		PartialDamage:  opts.PartialDamage,
+		Weather:        opts.Weather,
+		WeatherSeed:    opts.WeatherSeed,
+		Spectators:     opts.Spectators,
+		SpectatorDelay: opts.SpectatorDelay,
+		TurnTimeout:    opts.TurnTimeout,
+		MaxTimeouts:    opts.MaxTimeouts,
+	}
+}
+
+// applyEvent applies an event to the game state and appends it to the game's
+// event log. Live actions and Replay both go through here, so replaying a log
+// always reproduces the state the original actions produced.
+func applyEvent(game *models.Game, event models.Event) (models.Event, error) {
+	switch event.Type {
+	case models.EventGameCreated:
+		// The game itself is built by newGame, only the spectator token and
+		// the plant catalog are carried by the event
+		game.SpectatorToken = event.Token
+		game.Catalog = event.Catalog
+	case models.EventPlayerJoined:
+		if err := joinGame(game, event.Player, event.Token, event.Account, event.Team); err != nil {
+			return event, err
+		}
+		event.Team = game.Players[event.Player].Team
+	case models.EventBoardSet:
+		if event.Board == nil {
+			return event, fmt.Errorf("missing board in %s event", event.Type)
+		}
+		board := event.Board.Clone()
+		if err := setBoard(game, event.Player, board); err != nil {
+			return event, err
+		}
+		event.Board = board
+	case models.EventPlayerReady:
+		if err := setPlayerReady(game, event.Player); err != nil {
+			return event, err
+		}
+	case models.EventStrikeResolved:
+		result, err := strike(game, event.Player, event.Target, event.Coord)
+		if err != nil {
+			return event, err
+		}
+		event.Result = result
+	case models.EventTurnSkipped:
+		if err := skipTurn(game, event.Player); err != nil {
+			return event, err
+		}
+	case models.EventPlayerForfeit:
+		if err := forfeit(game, event.Player); err != nil {
+			return event, err
+		}
+	case models.EventGameEnded:
+		game.Status = models.GameStatusEnd
+		if event.Winner != nil {
+			winner := *event.Winner
+			game.Winner = &winner
+		}
+		if event.Team != 0 {
+			game.WinnerTeam = event.Team
+		}
+		game.Ranking = nil
+		for _, group := range FinishingOrder(game) {
+			game.Ranking = append(game.Ranking, group...)
+		}
+	default:
+		return event, fmt.Errorf("unknown event type: %s", event.Type)
+	}
+
+	// Move weather games on to the next round once everybody has played
+	updateWeather(game, event)
+
+	// Every new turn gets the full time limit
+	switch {
+	case game.Status != models.GameStatusInProgress || game.TurnTimeout == 0:
+		game.TurnDeadline = nil
+	case event.Type == models.EventPlayerReady, event.Type == models.EventStrikeResolved,
+		event.Type == models.EventTurnSkipped, event.Type == models.EventPlayerForfeit:
+		deadline := event.Time.Add(time.Duration(game.TurnTimeout) * time.Second)
+		game.TurnDeadline = &deadline
+	}
+
+	// Append the event to the log
+	event.GameID = game.ID
+	event.Seq = len(game.Events) + 1
+	event.Status = game.Status
+	event.Turn = game.Turn
+	game.Events = append(game.Events, event)
+
+	return event, nil
+}
+
+// record applies a new action to the game and logs it, followed by a
+// GameEnded event if the action finished the game
+func record(game *models.Game, event models.Event) (models.Event, error) {
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	ended := game.Status == models.GameStatusEnd
+	event, err := applyEvent(game, event)
+	if err != nil {
+		return event, err
+	}
+
+	if !ended && game.Status == models.GameStatusEnd {
+		_, err := applyEvent(game, models.Event{
+			Type:   models.EventGameEnded,
+			Time:   event.Time,
+			Winner: game.Winner,
+			Team:   game.WinnerTeam,
+		})
+		if err != nil {
+			return event, err
+		}
+	}
+
+	return event, nil
+}
+
+// Replay rebuilds a game from its event log. The log must start with the
+// GameCreated event; any prefix of a log gives the state at that point. A
+// recorded event was valid when it happened, so an event that fails to apply
+// means the log is corrupted or the rules have drifted from it.
+func Replay(events []models.Event) (*models.Game, error) {
+	if len(events) == 0 || events[0].Type != models.EventGameCreated || events[0].Options == nil {
+		return nil, errors.New("event log does not start with a GameCreated event")
+	}
+
+	game := newGame(events[0].GameID, *events[0].Options)
+	for _, event := range events {
+		if _, err := applyEvent(game, event); err != nil {
+			return nil, fmt.Errorf("replaying event %d (%s): %w", event.Seq, event.Type, err)
+		}
+	}
+
+	return game, nil
+}
@@ -0,0 +1,162 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
+)
+
+// replayBoard returns a board of a GAS and a WIND plant (400 MW) on the
+// given row and the one below it
+func replayBoard(row byte) *models.Board {
+	next := row + 1
+	return &models.Board{
+		Plants: []models.Plant{
+			{Type: models.PlantTypeGas, Coordinates: []string{string(row) + "1", string(row) + "2", string(next) + "1", string(next) + "2"}},
+			{Type: models.PlantTypeWind, Coordinates: []string{string(row) + "4", string(row) + "5"}},
+		},
+	}
+}
+
+// startGame creates a game through the manager where every player has a
+// replayBoard and is ready. Teams alternate in team games.
+func startGame(t *testing.T, gm *GameManager, opts models.GameOptions, players []string) *models.Game {
+	t.Helper()
+
+	game, err := gm.CreateGame(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range players {
+		team := 0
+		if opts.Teams {
+			team = i%2 + 1
+		}
+		if _, err := gm.JoinGame(game.ID, name, team); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := gm.SetBoard(game.ID, name, replayBoard(byte('A'+2*i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range players {
+		if err := gm.SetPlayerReady(game.ID, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	game, err = gm.GetGame(game.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return game
+}
+
+// playGame plays a game through the manager until it ends: each player
+// strikes the first plant cell still standing of the next opponent
+func playGame(t *testing.T, gm *GameManager, opts models.GameOptions, players []string) string {
+	t.Helper()
+
+	game := startGame(t, gm, opts, players)
+	for moves := 0; ; moves++ {
+		var err error
+		game, err = gm.GetGame(game.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if game.Status == models.GameStatusEnd {
+			return game.ID
+		}
+		if moves > 100 {
+			t.Fatal("the game did not end")
+		}
+
+		player := game.Turn
+		target, coord := "", ""
+		for _, name := range players {
+			info := game.Players[name]
+			if name == player || !info.InGame() || (game.Teams && info.Team == game.Players[player].Team) {
+				continue
+			}
+			for _, plant := range info.Board.Plants {
+				for _, cell := range plant.Coordinates {
+					if coord == "" && !contains(info.Board.Hits, cell) {
+						target, coord = name, cell
+					}
+				}
+			}
+			if coord != "" {
+				break
+			}
+		}
+		if _, err := gm.Strike(game.ID, player, target, coord); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReplayRebuildsStoredGame(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    models.GameOptions
+		players []string
+	}{
+		{"classic", models.GameOptions{Size: 10, Capacity: 400}, []string{"alice", "bob"}},
+		{"elimination", models.GameOptions{Size: 10, Capacity: 400, Elimination: true, PartialDamage: true}, []string{"alice", "bob", "carol"}},
+		{"teams", models.GameOptions{Size: 10, Capacity: 400, Teams: true, TurnTimeout: 60}, []string{"alice", "bob", "carol", "dave"}},
+		{"weather", models.GameOptions{Size: 10, Capacity: 400, Weather: true, WeatherSeed: 7}, []string{"alice", "bob"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gameStore := store.NewMemoryStore()
+			gm := NewGameManager(gameStore)
+			id := playGame(t, gm, tt.opts, tt.players)
+
+			stored, err := gameStore.Get(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			replayed, err := Replay(stored.Events)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The version is kept by the store, not by the log
+			stored.Version = 0
+			if !reflect.DeepEqual(replayed, stored) {
+				t.Fatalf("replayed game differs from the stored game:\n%+v\n%+v", replayed, stored)
+			}
+		})
+	}
+}
+
+func TestReplayReportsBadEvents(t *testing.T) {
+	gm := NewGameManager(store.NewMemoryStore())
+	game, err := gm.CreateGame(testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := gm.GetEvents(game.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A strike in a game that never started
+	events = append(events, models.Event{
+		Type:   models.EventStrikeResolved,
+		Seq:    len(events) + 1,
+		Player: "alice",
+		Target: "bob",
+		Coord:  "A1",
+	})
+	if _, err := Replay(events); err == nil {
+		t.Fatal("expected an error replaying an invalid strike")
+	}
+
+	if _, err := Replay(events[1:]); err == nil {
+		t.Fatal("expected an error replaying a log without GameCreated")
+	}
+}
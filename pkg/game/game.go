@@ -1,94 +1,294 @@
 package game
 
 import (
+	"crypto/rand"
 	"errors"
 	"fmt"
-	"math/rand"
+	"io"
 	"sort"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
 )
 
+// MaxPlayers is the maximum number of players in a game
+const MaxPlayers = 4
+
+// Game IDs are short enough to share, tokens long enough not to be guessed
+const (
+	idCharset    = "abcdefghijklmnopqrstuvwxyz0123456789"
+	idLength     = 8
+	tokenCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	tokenLength  = 32
+	// idRetries is how many IDs are tried when creating a game
+	idRetries = 5
+	// maxSpectatorDelay is the longest spectator delay, in moves
+	maxSpectatorDelay = 100
+	// Turn time limits, in seconds
+	minTurnTimeout = 5
+	maxTurnTimeout = 24 * 60 * 60
+	// Expired turns in a row before a player forfeits
+	defaultMaxTimeouts = 3
+	maxMaxTimeouts     = 10
+)
+
+// ErrPlayerExists is returned when joining a game with the name of one of
+// its players
+var ErrPlayerExists = errors.New("PLAYER_ALREADY_EXISTS")
+
 // GameManager manages all active games
 type GameManager struct {
-	games map[string]*models.Game
-	mutex sync.RWMutex
+	store   store.GameStore
+	hub     *Hub
+	signals signals
+
+	// random generates game IDs and player tokens
+	random      io.Reader
+	randomMutex sync.Mutex
+
+	// endHooks run every time a game ends
+	endHooks []func(game *models.Game)
+
+	// catalogs are the plant catalogs games can be created with, by name
+	catalogs map[string]*models.PlantCatalog
 }
 
-// NewGameManager creates a new game manager
-func NewGameManager() *GameManager {
-	return &GameManager{
-		games: make(map[string]*models.Game),
+// NewGameManager creates a new game manager backed by the given store
+func NewGameManager(gameStore store.GameStore) *GameManager {
+	gm := &GameManager{
+		store:    gameStore,
+		random:   rand.Reader,
+		catalogs: make(map[string]*models.PlantCatalog),
+	}
+	gm.hub = NewHub(gm.GetEvents)
+	gm.OnGameEnd(gm.updateRatings)
+	for _, catalog := range models.Catalogs {
+		gm.catalogs[catalog.Name] = catalog
+	}
+
+	return gm
+}
+
+// OnGameEnd registers a function called with the final state of every game
+// that ends through this manager. Hooks must be registered before the
+// manager is used.
+func (gm *GameManager) OnGameEnd(hook func(game *models.Game)) {
+	gm.endHooks = append(gm.endHooks, hook)
+}
+
+// SetRandom replaces the source of the game IDs and player tokens, which is
+// crypto/rand by default. Only simulations should use a predictable source
+// (e.g. a seeded math/rand) to get reproducible results.
+func (gm *GameManager) SetRandom(random io.Reader) {
+	gm.randomMutex.Lock()
+	defer gm.randomMutex.Unlock()
+
+	gm.random = random
+}
+
+// Tickets returns the store of the matchmaking queue, shared with the games
+// so every replica sees the same queue
+func (gm *GameManager) Tickets() store.TicketStore {
+	return gm.store
+}
+
+// Subscribe starts receiving the events recorded in a game
+func (gm *GameManager) Subscribe(gameID string) *Subscription {
+	return gm.hub.Subscribe(gameID)
+}
+
+// update applies fn to a stored game and publishes the events it recorded
+func (gm *GameManager) update(gameID string, fn store.UpdateFunc) (*models.Game, error) {
+	recorded := 0
+	game, err := gm.store.Update(gameID, func(game *models.Game) error {
+		recorded = len(game.Events)
+		return fn(game)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if recorded < len(game.Events) {
+		gm.hub.Publish(gameID, game.Events)
+	}
+	gm.signals.signal(gameID)
+
+	// Run the end hooks once, when the game ends
+	for _, event := range game.Events[recorded:] {
+		if event.Type == models.EventGameEnded {
+			for _, hook := range gm.endHooks {
+				hook(game.Clone())
+			}
+		}
 	}
+
+	return game, nil
 }
 
-// CreateGame creates a new game with the given parameters
-func (gm *GameManager) CreateGame(size int, capacity int, public bool) (*models.Game, error) {
+// CreateGame creates a new game with the given options
+func (gm *GameManager) CreateGame(opts models.GameOptions) (*models.Game, error) {
 	// Validate parameters
-	if size < 5 || size > 20 {
+	if opts.Size < 5 || opts.Size > 20 {
 		return nil, errors.New("size should be between 5 and 20")
 	}
-	if capacity <= 0 {
+	if opts.Capacity <= 0 {
 		return nil, errors.New("capacity should be greater than 0")
 	}
+	switch opts.Spectators {
+	case "":
+		opts.Spectators = models.SpectatorBlind
+	case models.SpectatorBlind, models.SpectatorFull, models.SpectatorDelayed:
+	default:
+		return nil, errors.New("spectators should be blind, full or delayed")
+	}
+	// Private games only show their boards to their players
+	if opts.Spectators != models.SpectatorBlind && !opts.Public {
+		return nil, errors.New("full and delayed spectators need a public game")
+	}
+	if opts.Spectators != models.SpectatorDelayed {
+		opts.SpectatorDelay = 0
+	} else if opts.SpectatorDelay < 1 || opts.SpectatorDelay > maxSpectatorDelay {
+		return nil, fmt.Errorf("spectator delay should be between 1 and %d moves", maxSpectatorDelay)
+	}
 
-	// Create empty player info map
-	playerInfoMap := make(map[string]models.PlayerInfo)
+	if opts.Catalog == "" {
+		opts.Catalog = models.DefaultCatalog.Name
+	}
+	catalog, exists := gm.catalogs[opts.Catalog]
+	if !exists {
+		return nil, fmt.Errorf("unknown plant catalog: %s", opts.Catalog)
+	}
 
-	// Generate a unique ID
-	id := generateID()
+	// Team games go on until a whole team is knocked out
+	if opts.Teams {
+		opts.Elimination = true
+	}
 
-	// Create the game
-	game := &models.Game{
-		ID:       id,
-		Status:   models.GameStatusPending,
-		Turn:     "",
-		Winner:   nil,
-		Players:  playerInfoMap,
-		Size:     size,
-		Capacity: capacity,
-		Public:   public,
+	if opts.TurnTimeout == 0 {
+		opts.MaxTimeouts = 0
+	} else {
+		if opts.TurnTimeout < minTurnTimeout || opts.TurnTimeout > maxTurnTimeout {
+			return nil, fmt.Errorf("turn timeout should be between %d and %d seconds", minTurnTimeout, maxTurnTimeout)
+		}
+		if opts.MaxTimeouts == 0 {
+			opts.MaxTimeouts = defaultMaxTimeouts
+		}
+		if opts.MaxTimeouts < 1 || opts.MaxTimeouts > maxMaxTimeouts {
+			return nil, fmt.Errorf("max timeouts should be between 1 and %d", maxMaxTimeouts)
+		}
 	}
 
-	// Store the game
-	gm.mutex.Lock()
-	gm.games[id] = game
-	gm.mutex.Unlock()
+	// The weather of every round comes from the seed of the game
+	if !opts.Weather {
+		opts.WeatherSeed = 0
+	} else if opts.WeatherSeed == 0 {
+		seed, err := gm.generateWeatherSeed()
+		if err != nil {
+			return nil, err
+		}
+		opts.WeatherSeed = seed
+	}
 
-	return game, nil
+	// Spectators share a read-only token
+	spectatorToken, err := gm.generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the game with a unique ID, trying again if the ID is taken
+	for i := 0; i < idRetries; i++ {
+		id, err := gm.generateID()
+		if err != nil {
+			return nil, err
+		}
+
+		game := newGame(id, opts)
+		_, err = record(game, models.Event{
+			Type:    models.EventGameCreated,
+			Options: &opts,
+			Catalog: catalog,
+			Token:   spectatorToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// Store the game
+		err = gm.store.Create(game)
+		if errors.Is(err, store.ErrExists) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return game, nil
+	}
+
+	return nil, errors.New("could not generate a unique game ID")
 }
 
-// JoinGame allows a player to join an existing game
-func (gm *GameManager) JoinGame(gameID string, playerName string) (string, error) {
-	gm.mutex.Lock()
-	defer gm.mutex.Unlock()
+// JoinGame allows a player to join an existing game as a guest. In team
+// games the player joins the given team, or the smallest one when team is 0.
+// Names of registered accounts can only be used through JoinAccount.
+func (gm *GameManager) JoinGame(gameID string, playerName string, team int) (string, error) {
+	if _, err := gm.store.GetAccount(playerName); err == nil {
+		return "", errors.New("NAME_REGISTERED")
+	} else if !errors.Is(err, store.ErrAccountNotFound) {
+		return "", err
+	}
 
-	// Get the game
-	game, exists := gm.games[gameID]
-	if !exists {
-		return "", errors.New("game not found")
+	return gm.join(gameID, playerName, false, team)
+}
+
+// join adds a player to a game and returns its token
+func (gm *GameManager) join(gameID string, playerName string, account bool, team int) (string, error) {
+	// Generate a random token for the player
+	token, err := gm.generateToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = gm.update(gameID, func(game *models.Game) error {
+		_, err := record(game, models.Event{
+			Type:    models.EventPlayerJoined,
+			Player:  playerName,
+			Token:   token,
+			Account: account,
+			Team:    team,
+		})
+		return err
+	})
+	if err != nil {
+		return "", err
 	}
 
+	return token, nil
+}
+
+// joinGame adds a player to the game
+func joinGame(game *models.Game, playerName string, token string, account bool, team int) error {
 	// Check if the game is still in PENDING status
 	if game.Status != models.GameStatusPending {
-		return "", errors.New("GAME_ALREADY_STARTED")
+		return errors.New("GAME_ALREADY_STARTED")
 	}
 
-	// Check if max players limit (4) is reached
-	if len(game.Players) >= 4 {
-		return "", errors.New("game is full (max 4 players)")
+	// Check if max players limit is reached
+	if len(game.Players) >= MaxPlayers {
+		return errors.New("game is full (max 4 players)")
 	}
 
 	// Check if the player already exists
 	if _, exists := game.Players[playerName]; exists {
-		return "", errors.New("player already exists in this game")
+		return ErrPlayerExists
 	}
 
-	// Generate a random token for the player
-	token := generateToken()
+	team, err := assignTeam(game, team)
+	if err != nil {
+		return err
+	}
 
 	// Add the player to the game
 	game.Players[playerName] = models.PlayerInfo{
@@ -96,6 +296,8 @@ func (gm *GameManager) JoinGame(gameID string, playerName string) (string, error
 		TotalCapacity: 0,
 		Capacity:      0,
 		Token:         token,
+		Account:       account,
+		Team:          team,
 		Board:         &models.Board{},
 	}
 
@@ -112,82 +314,104 @@ func (gm *GameManager) JoinGame(gameID string, playerName string) (string, error
 		game.Turn = players[0]
 	}
 
-	return token, nil
+	return nil
 }
 
 // GetGame retrieves a game by ID
 func (gm *GameManager) GetGame(id string) (*models.Game, error) {
-	gm.mutex.RLock()
-	defer gm.mutex.RUnlock()
+	return gm.store.Get(id)
+}
 
-	game, exists := gm.games[id]
-	if !exists {
-		return nil, errors.New("game not found")
+// DeleteGame removes a game
+func (gm *GameManager) DeleteGame(id string) error {
+	return gm.store.Delete(id)
+}
+
+// GetEvents retrieves the event log of a game
+func (gm *GameManager) GetEvents(id string) ([]models.Event, error) {
+	game, err := gm.store.Get(id)
+	if err != nil {
+		return nil, err
 	}
 
-	return game, nil
+	return game.Events, nil
 }
 
 // SetBoard sets a player's board
 func (gm *GameManager) SetBoard(gameID string, playerName string, board *models.Board) (*models.Board, error) {
-	gm.mutex.Lock()
-	defer gm.mutex.Unlock()
-
-	// Get the game
-	game, exists := gm.games[gameID]
-	if !exists {
-		return nil, errors.New("game not found")
+	var event models.Event
+	_, err := gm.update(gameID, func(game *models.Game) error {
+		var err error
+		event, err = record(game, models.Event{
+			Type:   models.EventBoardSet,
+			Player: playerName,
+			Board:  board,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	return event.Board, nil
+}
+
+// setBoard validates and places a player's board
+func setBoard(game *models.Game, playerName string, board *models.Board) error {
 	// Check if the game is still in PENDING status
 	if game.Status != models.GameStatusPending {
-		return nil, errors.New("GAME_ALREADY_STARTED")
+		return errors.New("GAME_ALREADY_STARTED")
 	}
 
 	// Check if the player exists
 	playerInfo, exists := game.Players[playerName]
 	if !exists {
-		return nil, errors.New("player not found")
+		return errors.New("player not found")
 	}
 
 	// Validate the board
-	if err := validateBoard(board, game.Size, game.Capacity); err != nil {
-		return nil, err
+	catalog := game.PlantCatalog()
+	if err := validateBoard(board, game.Size, catalog); err != nil {
+		return err
 	}
 
 	// Calculate total capacity
 	totalCapacity := 0
 	for _, plant := range board.Plants {
-		totalCapacity += models.PlantCapacity(plant.Type)
+		totalCapacity += catalog.Capacity(plant.Type)
 	}
 
 	// Check if the total capacity meets the requirements
 	if totalCapacity < game.Capacity || totalCapacity > int(float64(game.Capacity)*2) {
-		return nil, fmt.Errorf("total capacity should be between %d and %d", game.Capacity, int(float64(game.Capacity)*1.1))
+		return fmt.Errorf("total capacity should be between %d and %d", game.Capacity, int(float64(game.Capacity)*1.1))
 	}
 
 	// Update the player's board
 	board.TotalCapacity = totalCapacity
 	board.Capacity = totalCapacity
-	playerInfo.Board = board
+	playerInfo.Board = board.Clone()
 	playerInfo.TotalCapacity = totalCapacity
 	playerInfo.Capacity = totalCapacity
 	game.Players[playerName] = playerInfo
 
-	return board, nil
+	return nil
 }
 
 // SetPlayerReady sets a player as ready
 func (gm *GameManager) SetPlayerReady(gameID string, playerName string) error {
-	gm.mutex.Lock()
-	defer gm.mutex.Unlock()
+	_, err := gm.update(gameID, func(game *models.Game) error {
+		_, err := record(game, models.Event{
+			Type:   models.EventPlayerReady,
+			Player: playerName,
+		})
+		return err
+	})
 
-	// Get the game
-	game, exists := gm.games[gameID]
-	if !exists {
-		return errors.New("game not found")
-	}
+	return err
+}
 
+// setPlayerReady marks a player as ready and starts the game once everyone is
+func setPlayerReady(game *models.Game, playerName string) error {
 	// Check if the game is still in PENDING status
 	if game.Status != models.GameStatusPending {
 		return errors.New("GAME_ALREADY_STARTED")
@@ -217,14 +441,22 @@ func (gm *GameManager) SetPlayerReady(gameID string, playerName string) error {
 		}
 	}
 
-	// If all players are ready and there are at least 2 players, start the game
-	if allReady && len(game.Players) >= 2 {
+	// Team games start once both teams are full
+	minPlayers := 2
+	if game.Teams {
+		minPlayers = teamCount * teamSize
+	}
+
+	// If all players are ready and there are enough players, start the game
+	if allReady && len(game.Players) >= minPlayers {
 		game.Status = models.GameStatusInProgress
 
 		// Set the turn to the first player in alphabetical order
 		players := make([]string, 0, len(game.Players))
-		for player := range game.Players {
-			players = append(players, player)
+		for player, info := range game.Players {
+			if !game.Teams || info.Team == 1 {
+				players = append(players, player)
+			}
 		}
 		sort.Strings(players)
 		game.Turn = players[0]
@@ -235,15 +467,26 @@ func (gm *GameManager) SetPlayerReady(gameID string, playerName string) error {
 
 // Strike performs a strike action
 func (gm *GameManager) Strike(gameID string, playerName string, targetName string, coord string) (string, error) {
-	gm.mutex.Lock()
-	defer gm.mutex.Unlock()
-
-	// Get the game
-	game, exists := gm.games[gameID]
-	if !exists {
-		return "", errors.New("game not found")
+	var event models.Event
+	_, err := gm.update(gameID, func(game *models.Game) error {
+		var err error
+		event, err = record(game, models.Event{
+			Type:   models.EventStrikeResolved,
+			Player: playerName,
+			Target: targetName,
+			Coord:  coord,
+		})
+		return err
+	})
+	if err != nil {
+		return "", err
 	}
 
+	return event.Result, nil
+}
+
+// strike resolves a strike against the target's board and advances the turn
+func strike(game *models.Game, playerName string, targetName string, coord string) (string, error) {
 	// Check if the game is in progress
 	if game.Status != models.GameStatusInProgress {
 		return "", errors.New("game is not in progress")
@@ -260,6 +503,15 @@ func (gm *GameManager) Strike(gameID string, playerName string, targetName strin
 	if !playerExists || !targetExists {
 		return "", errors.New("INVALID_PLAYER")
 	}
+	if targetInfo.Forfeited {
+		return "", errors.New("PLAYER_FORFEITED")
+	}
+	if targetInfo.Eliminated {
+		return "", errors.New("PLAYER_ELIMINATED")
+	}
+	if game.Teams && targetInfo.Team == game.Players[playerName].Team {
+		return "", errors.New("CANNOT_STRIKE_TEAMMATE")
+	}
 
 	// Validate the coordinate
 	if err := models.ValidateCoordinate(coord, game.Size); err != nil {
@@ -280,42 +532,63 @@ func (gm *GameManager) Strike(gameID string, playerName string, targetName strin
 
 	// Check if the coordinate hits a plant
 	hit := false
-	var hitPlantType models.PlantType
-	var hitPlantCoords []string
+	var hitPlant models.Plant
 
 	for _, plant := range targetInfo.Board.Plants {
-		for _, plantCoord := range plant.Coordinates {
-			if plantCoord == coord {
-				hit = true
-				hitPlantType = plant.Type
-				hitPlantCoords = plant.Coordinates
-				break
-			}
-		}
-		if hit {
+		if contains(plant.Coordinates, coord) {
+			hit = true
+			hitPlant = plant
 			break
 		}
 	}
 
 	// Update the board based on the strike result
+	destroyed := false
 	if hit {
-		// Add all plant coordinates to hits
-		for _, plantCoord := range hitPlantCoords {
-			if !contains(targetInfo.Board.Hits, plantCoord) {
-				targetInfo.Board.Hits = append(targetInfo.Board.Hits, plantCoord)
+		plantCapacity := game.PlantCatalog().Capacity(hitPlant.Type)
+		damage := plantCapacity
+		if game.PartialDamage {
+			// Each cell takes its share of the capacity, rounded so the
+			// shares of all the cells add up to the plant capacity
+			struck := 0
+			for _, plantCoord := range hitPlant.Coordinates {
+				if contains(targetInfo.Board.Hits, plantCoord) {
+					struck++
+				}
+			}
+			cells := len(hitPlant.Coordinates)
+			damage = plantCapacity*(struck+1)/cells - plantCapacity*struck/cells
+			targetInfo.Board.Hits = append(targetInfo.Board.Hits, coord)
+			destroyed = struck+1 == cells
+		} else {
+			// Add all plant coordinates to hits
+			for _, plantCoord := range hitPlant.Coordinates {
+				if !contains(targetInfo.Board.Hits, plantCoord) {
+					targetInfo.Board.Hits = append(targetInfo.Board.Hits, plantCoord)
+				}
 			}
+			destroyed = true
+		}
+		if destroyed {
+			targetInfo.Board.Destroyed = append(targetInfo.Board.Destroyed, hitPlant.Coordinates...)
 		}
 
 		// Reduce capacity
-		plantCapacity := models.PlantCapacity(hitPlantType)
-		targetInfo.Capacity -= plantCapacity
-		targetInfo.Board.Capacity -= plantCapacity
+		targetInfo.Capacity -= damage
+		targetInfo.Board.Capacity -= damage
+		if game.Weather {
+			targetInfo.Capacity = effectiveCapacity(game, targetInfo.Board)
+		}
 
 		// Check if the target has lost
-		if targetInfo.Capacity <= int(float64(targetInfo.TotalCapacity)*0.1) {
-			game.Status = models.GameStatusEnd
-			winner := playerName
-			game.Winner = &winner
+		if knockedOut(game, targetInfo) {
+			if game.Elimination {
+				targetInfo.Eliminated = true
+			} else {
+				game.Status = models.GameStatusEnd
+				winner := playerName
+				game.Winner = &winner
+			}
 		}
 	} else {
 		// Add to misses
@@ -324,45 +597,36 @@ func (gm *GameManager) Strike(gameID string, playerName string, targetName strin
 
 	// Update the game state
 	game.Players[targetName] = targetInfo
+	if targetInfo.Eliminated {
+		leaveGame(game, targetName)
+	}
+
+	// Playing resets the count of expired turns
+	playerInfo := game.Players[playerName]
+	playerInfo.Timeouts = 0
+	game.Players[playerName] = playerInfo
 
 	// Update the turn if the game is still in progress
 	if game.Status == models.GameStatusInProgress {
-		// Find the next player
-		players := make([]string, 0, len(game.Players))
-		for player := range game.Players {
-			players = append(players, player)
-		}
-		sort.Strings(players)
-
-		// Find current player's index
-		currentIndex := -1
-		for i, player := range players {
-			if player == playerName {
-				currentIndex = i
-				break
-			}
-		}
-
-		// Set turn to next player (cycling through all players)
-		nextIndex := (currentIndex + 1) % len(players)
-		game.Turn = players[nextIndex]
+		game.Turn = nextTurn(game, playerName)
 	}
 
-	if hit {
+	switch {
+	case hit && destroyed && game.PartialDamage:
+		return "DESTROYED", nil
+	case hit:
 		return "HIT", nil
+	default:
+		return "MISS", nil
 	}
-	return "MISS", nil
 }
 
 // GetPlayerBoard retrieves a player's board
 func (gm *GameManager) GetPlayerBoard(gameID string, playerName string) (*models.Board, error) {
-	gm.mutex.RLock()
-	defer gm.mutex.RUnlock()
-
 	// Get the game
-	game, exists := gm.games[gameID]
-	if !exists {
-		return nil, errors.New("game not found")
+	game, err := gm.store.Get(gameID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Check if the player exists
@@ -376,13 +640,10 @@ func (gm *GameManager) GetPlayerBoard(gameID string, playerName string) (*models
 
 // GetOpponentBlindBoard retrieves an opponent's blind board
 func (gm *GameManager) GetOpponentBlindBoard(gameID string, opponentName string) (*models.Board, error) {
-	gm.mutex.RLock()
-	defer gm.mutex.RUnlock()
-
 	// Get the game
-	game, exists := gm.games[gameID]
-	if !exists {
-		return nil, errors.New("game not found")
+	game, err := gm.store.Get(gameID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Check if the opponent exists
@@ -397,13 +658,10 @@ func (gm *GameManager) GetOpponentBlindBoard(gameID string, opponentName string)
 
 // GetBoardMap generates an ASCII representation of a player's board
 func (gm *GameManager) GetBoardMap(gameID string, playerName string, blind bool) (string, error) {
-	gm.mutex.RLock()
-	defer gm.mutex.RUnlock()
-
 	// Get the game
-	game, exists := gm.games[gameID]
-	if !exists {
-		return "", errors.New("game not found")
+	game, err := gm.store.Get(gameID)
+	if err != nil {
+		return "", err
 	}
 
 	// Check if the player exists
@@ -413,7 +671,7 @@ func (gm *GameManager) GetBoardMap(gameID string, playerName string, blind bool)
 	}
 
 	// Generate the ASCII map
-	return playerInfo.Board.GenerateASCIIMap(game.Size, blind), nil
+	return playerInfo.Board.GenerateASCIIMap(game.Size, blind, game.PlantCatalog()), nil
 }
 
 // FormatGameStatus returns a string representation of the game status
@@ -443,32 +701,114 @@ func FormatGameStatus(game *models.Game) string {
 	return result.String()
 }
 
+// nextTurn returns the player playing after the given one, cycling through
+// the players still in the game in alphabetical order. In team games the
+// teams take turns instead.
+func nextTurn(game *models.Game, playerName string) string {
+	if game.Teams {
+		return nextTeamTurn(game, playerName)
+	}
+
+	players := make([]string, 0, len(game.Players))
+	for player, info := range game.Players {
+		if info.InGame() || player == playerName {
+			players = append(players, player)
+		}
+	}
+	sort.Strings(players)
+
+	for i, player := range players {
+		if player == playerName {
+			for j := 1; j < len(players); j++ {
+				next := players[(i+j)%len(players)]
+				if game.Players[next].InGame() {
+					return next
+				}
+			}
+		}
+	}
+
+	return playerName
+}
+
+// knockedOut reports whether a player has lost too much capacity to stay in
+// the game. In weather games the limit follows the weather of the round, so
+// a calm round does not turn any hit on a WIND or SOLAR player into a
+// knockout.
+func knockedOut(game *models.Game, info models.PlayerInfo) bool {
+	total := info.TotalCapacity
+	if game.Weather {
+		total = roundCapacity(game, info.Board)
+	}
+	return info.Capacity <= int(float64(total)*0.1)
+}
+
+// leaveGame gives its final place to a player that is out of the game and
+// ends the game when a single player, or team, is left
+func leaveGame(game *models.Game, playerName string) {
+	var remaining []string
+	teams := make(map[int]bool)
+	for name, info := range game.Players {
+		if info.InGame() {
+			remaining = append(remaining, name)
+			teams[info.Team] = true
+		}
+	}
+	sort.Strings(remaining)
+
+	playerInfo := game.Players[playerName]
+	playerInfo.Place = len(remaining) + 1
+	game.Players[playerName] = playerInfo
+
+	if len(remaining) == 1 || (game.Teams && len(teams) == 1) {
+		game.Status = models.GameStatusEnd
+		winner := remaining[0]
+		game.Winner = &winner
+		if game.Teams {
+			game.WinnerTeam = game.Players[winner].Team
+		}
+	}
+}
+
 // Helper functions
 
-// generateID generates a unique ID for a game
-func generateID() string {
-	rand.Seed(time.Now().UnixNano())
-	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
-	b := make([]byte, 8)
-	for i := range b {
-		b[i] = charset[rand.Intn(len(charset))]
+// randomString generates a random string from the charset
+func (gm *GameManager) randomString(charset string, length int) (string, error) {
+	gm.randomMutex.Lock()
+	defer gm.randomMutex.Unlock()
+
+	// Discard the bytes above the largest multiple of the charset length,
+	// so every character is equally likely
+	limit := 256 - 256%len(charset)
+
+	b := make([]byte, length)
+	buf := make([]byte, 1)
+	for i := 0; i < length; {
+		if _, err := io.ReadFull(gm.random, buf); err != nil {
+			return "", err
+		}
+		if int(buf[0]) >= limit {
+			continue
+		}
+		b[i] = charset[int(buf[0])%len(charset)]
+		i++
 	}
-	return string(b)
+	return string(b), nil
+}
+
+// generateID generates a random ID for a game
+func (gm *GameManager) generateID() (string, error) {
+	return gm.randomString(idCharset, idLength)
 }
 
 // generateToken generates a random token for player authentication
-func generateToken() string {
-	rand.Seed(time.Now().UnixNano())
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, 10)
-	for i := range b {
-		b[i] = charset[rand.Intn(len(charset))]
-	}
-	return string(b)
+func (gm *GameManager) generateToken() (string, error) {
+	return gm.randomString(tokenCharset, tokenLength)
 }
 
-// validateBoard validates a board configuration
-func validateBoard(board *models.Board, size int, requiredCapacity int) error {
+// validateBoard validates a board configuration against the plants of a
+// catalog
+func validateBoard(board *models.Board, size int, catalog *models.PlantCatalog) error {
 	// Check if the board has plants
 	if len(board.Plants) == 0 {
 		return errors.New("board has no plants")
@@ -481,18 +821,22 @@ func validateBoard(board *models.Board, size int, requiredCapacity int) error {
 	}
 
 	// Check each plant
-	for _, plant := range board.Plants {
+	counts := make(map[models.PlantType]int)
+	for i, plant := range board.Plants {
 		// Validate plant type
-		switch plant.Type {
-		case models.PlantTypeNuclear, models.PlantTypeGas, models.PlantTypeWind, models.PlantTypeSolar:
-			// Valid plant type
-		default:
+		spec, exists := catalog.Plant(plant.Type)
+		if !exists {
 			return fmt.Errorf("invalid plant type: %s", plant.Type)
 		}
 
+		// Check the number of plants of this type
+		counts[plant.Type]++
+		if spec.Count > 0 && counts[plant.Type] > spec.Count {
+			return fmt.Errorf("too many %s plants: at most %d allowed", plant.Type, spec.Count)
+		}
+
 		// Get the expected size of the plant
-		plantSize := models.PlantSize(plant.Type)
-		expectedCoords := plantSize[0] * plantSize[1]
+		expectedCoords := len(spec.Footprint())
 
 		// Check if the number of coordinates matches the expected size
 		if len(plant.Coordinates) != expectedCoords {
@@ -527,50 +871,38 @@ func validateBoard(board *models.Board, size int, requiredCapacity int) error {
 		}
 
 		// Validate plant shape
-		if err := validatePlantShape(plant, size); err != nil {
+		shape, err := validatePlantShape(plant, spec)
+		if err != nil {
 			return err
 		}
+		board.Plants[i].Orientation = shape.Orientation()
+		board.Plants[i].Rotation = shape.Rotation
+		board.Plants[i].Mirrored = shape.Mirrored
 	}
 
 	return nil
 }
 
-// validatePlantShape validates that a plant's coordinates form the correct shape
-func validatePlantShape(plant models.Plant, size int) error {
-	// Get the expected shape of the plant
-	plantSize := models.PlantSize(plant.Type)
-	width := plantSize[0]
-
+// validatePlantShape validates that a plant's coordinates form the footprint
+// of its type, in any rotation (or mirrored when allowed), and returns the
+// shape they form
+func validatePlantShape(plant models.Plant, spec models.PlantSpec) (models.PlantShape, error) {
 	// Parse all coordinates
 	coords := make([][2]int, len(plant.Coordinates))
 	for i, coord := range plant.Coordinates {
 		y, x, err := models.ParseCoordinate(coord)
 		if err != nil {
-			return err
+			return models.PlantShape{}, err
 		}
 		coords[i] = [2]int{y, x}
 	}
 
-	// Sort coordinates by y, then by x
-	sort.Slice(coords, func(i, j int) bool {
-		if coords[i][0] == coords[j][0] {
-			return coords[i][1] < coords[j][1]
-		}
-		return coords[i][0] < coords[j][0]
-	})
-
-	// Check if the coordinates form a rectangle of the correct size
-	minY, minX := coords[0][0], coords[0][1]
-	for i := 0; i < len(coords); i++ {
-		expectedY := minY + (i / width)
-		expectedX := minX + (i % width)
-
-		if coords[i][0] != expectedY || coords[i][1] != expectedX {
-			return fmt.Errorf("invalid plant shape for %s", plant.Type)
-		}
+	shape, ok := spec.MatchShape(coords)
+	if !ok {
+		return models.PlantShape{}, fmt.Errorf("invalid plant shape for %s", plant.Type)
 	}
 
-	return nil
+	return shape, nil
 }
 
 // contains checks if a slice contains a string
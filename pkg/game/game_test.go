@@ -0,0 +1,320 @@
+package game
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
+)
+
+// countingStore counts the games the manager tries to create and how many
+// of them were refused because the ID was taken
+type countingStore struct {
+	store.GameStore
+
+	mutex   sync.Mutex
+	creates int
+	exists  int
+}
+
+func (s *countingStore) Create(game *models.Game) error {
+	err := s.GameStore.Create(game)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.creates++
+	if errors.Is(err, store.ErrExists) {
+		s.exists++
+	}
+
+	return err
+}
+
+// testOptions are the options of the games created by the tests
+var testOptions = models.GameOptions{Size: 10, Capacity: 1000}
+
+func TestCreateGameConcurrentIDsAreUnique(t *testing.T) {
+	const games = 5000
+
+	gameStore := store.NewMemoryStore()
+	gm := NewGameManager(gameStore)
+
+	ids := make(chan string, games)
+	var wg sync.WaitGroup
+	for i := 0; i < games; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			game, err := gm.CreateGame(testOptions)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			ids <- game.ID
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[string]bool)
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("game ID %s was given twice", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != games {
+		t.Fatalf("expected %d games, got %d", games, len(seen))
+	}
+
+	stored, err := gameStore.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != games {
+		t.Fatalf("expected %d stored games, got %d", games, len(stored))
+	}
+}
+
+func TestCreateGameRetriesTakenID(t *testing.T) {
+	gameStore := &countingStore{GameStore: store.NewMemoryStore()}
+	gm := NewGameManager(gameStore)
+
+	// The first game gets the spectator token and ID made of the first
+	// character of each charset
+	gm.SetRandom(bytes.NewReader(make([]byte, tokenLength+idLength)))
+	first, err := gm.CreateGame(testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second game draws the same token and ID, then another ID
+	retry := append(make([]byte, tokenLength+idLength), bytes.Repeat([]byte{1}, idLength)...)
+	gm.SetRandom(bytes.NewReader(retry))
+	second, err := gm.CreateGame(testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.ID == second.ID {
+		t.Fatalf("both games got ID %s", first.ID)
+	}
+	if gameStore.creates != 3 || gameStore.exists != 1 {
+		t.Fatalf("expected 3 creates with 1 taken ID, got %d creates with %d taken IDs", gameStore.creates, gameStore.exists)
+	}
+}
+
+func TestCreateGameGivesUpAfterRetries(t *testing.T) {
+	gameStore := &countingStore{GameStore: store.NewMemoryStore()}
+	gm := NewGameManager(gameStore)
+
+	// A source that always draws the same ID
+	gm.SetRandom(zeroReader{})
+	if _, err := gm.CreateGame(testOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := gm.CreateGame(testOptions); err == nil {
+		t.Fatal("expected an error when every ID is taken")
+	}
+	if gameStore.creates != 1+idRetries || gameStore.exists != idRetries {
+		t.Fatalf("expected %d creates with %d taken IDs, got %d creates with %d taken IDs",
+			1+idRetries, idRetries, gameStore.creates, gameStore.exists)
+	}
+}
+
+func TestCreateGameRandomError(t *testing.T) {
+	gm := NewGameManager(store.NewMemoryStore())
+
+	// The source runs out in the middle of the ID
+	gm.SetRandom(io.LimitReader(zeroReader{}, tokenLength+idLength/2))
+	if _, err := gm.CreateGame(testOptions); err == nil {
+		t.Fatal("expected an error when the random source fails")
+	}
+}
+
+// zeroReader is a random source that only draws zeros
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func TestStrikeDamage(t *testing.T) {
+	nuclear := []string{"A1", "A2", "A3", "B1", "B2", "B3", "C1", "C2", "C3"}
+	board := func() *models.Board {
+		return &models.Board{Plants: []models.Plant{
+			{Type: models.PlantTypeNuclear, Coordinates: nuclear},
+			{Type: models.PlantTypeGas, Coordinates: []string{"E1", "E2", "F1", "F2"}},
+		}}
+	}
+
+	type step struct {
+		coord     string
+		result    string
+		capacity  int
+		hits      int
+		destroyed int
+		err       string
+	}
+	// bob starts at 1300 MW and is never knocked out. With partial damage
+	// the first eight NUCLEAR cells take 111 MW and the last one 112, so the
+	// plant is left with exactly 0.
+	partial := make([]step, 0, len(nuclear))
+	for i, coord := range nuclear {
+		partial = append(partial, step{coord: coord, result: "HIT", capacity: 1300 - 1000*(i+1)/9, hits: i + 1})
+	}
+	partial[len(partial)-1].result = "DESTROYED"
+	partial[len(partial)-1].destroyed = len(nuclear)
+
+	tests := []struct {
+		name          string
+		partialDamage bool
+		steps         []step
+	}{
+		{
+			name: "whole plant",
+			steps: []step{
+				{coord: "B2", result: "HIT", capacity: 300, hits: 9, destroyed: 9},
+				{coord: "A1", err: "coordinate already hit"},
+				{coord: "J10", result: "MISS", capacity: 300, hits: 9, destroyed: 9},
+			},
+		},
+		{name: "partial damage", partialDamage: true, steps: partial},
+	}
+	for _, test := range tests {
+		gm := NewGameManager(store.NewMemoryStore())
+		game, err := gm.CreateGame(models.GameOptions{Size: 10, Capacity: 1000, PartialDamage: test.partialDamage})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"alice", "bob"} {
+			if _, err := gm.JoinGame(game.ID, name, 0); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := gm.SetBoard(game.ID, name, board()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, name := range []string{"alice", "bob"} {
+			if err := gm.SetPlayerReady(game.ID, name); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// bob only misses, on the last row of alice's board
+		misses := 0
+		for i, step := range test.steps {
+			game, err := gm.GetGame(game.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if game.Turn == "bob" {
+				misses++
+				if _, err := gm.Strike(game.ID, "bob", "alice", fmt.Sprintf("J%d", misses)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := gm.Strike(game.ID, "alice", "bob", step.coord)
+			if step.err != "" {
+				if err == nil || err.Error() != step.err {
+					t.Fatalf("%s, step %d: expected %s, got %v", test.name, i, step.err, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%s, step %d: %v", test.name, i, err)
+			}
+			if result != step.result {
+				t.Fatalf("%s, step %d: expected %s, got %s", test.name, i, step.result, result)
+			}
+
+			game, err = gm.GetGame(game.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			bob := game.Players["bob"]
+			if bob.Capacity != step.capacity || bob.Board.Capacity != step.capacity {
+				t.Fatalf("%s, step %d: expected %d MW, got %d (board %d)", test.name, i, step.capacity, bob.Capacity, bob.Board.Capacity)
+			}
+			if len(bob.Board.Hits) != step.hits || len(bob.Board.Destroyed) != step.destroyed {
+				t.Fatalf("%s, step %d: expected %d hits and %d destroyed cells, got %v and %v", test.name, i, step.hits, step.destroyed, bob.Board.Hits, bob.Board.Destroyed)
+			}
+			if game.Status != models.GameStatusInProgress {
+				t.Fatalf("%s, step %d: expected the game to go on, got %s", test.name, i, game.Status)
+			}
+		}
+	}
+}
+
+func TestEliminationGameGoesOn(t *testing.T) {
+	gm := NewGameManager(store.NewMemoryStore())
+	opts := models.GameOptions{Size: 10, Capacity: 400, Elimination: true}
+	game := startGame(t, gm, opts, []string{"alice", "bob", "carol"})
+
+	// Each board has a GAS plant at the first two columns of its rows and a
+	// WIND plant at columns 4 and 5: losing both knocks a player out
+	type step struct {
+		player, target, coord string
+		err                   string
+		turn                  string
+	}
+	steps := []step{
+		{player: "alice", target: "carol", coord: "E1", turn: "bob"},
+		{player: "bob", target: "carol", coord: "J1", turn: "carol"},
+		{player: "carol", target: "bob", coord: "J1", turn: "alice"},
+		// carol is out, bob plays next
+		{player: "alice", target: "carol", coord: "E4", turn: "bob"},
+		{player: "bob", target: "carol", coord: "J2", err: "PLAYER_ELIMINATED"},
+		// the turns skip carol
+		{player: "bob", target: "alice", coord: "A1", turn: "alice"},
+		{player: "alice", target: "bob", coord: "C1", turn: "bob"},
+		// alice is out, bob is the last player left
+		{player: "bob", target: "alice", coord: "A4"},
+	}
+	for i, step := range steps {
+		_, err := gm.Strike(game.ID, step.player, step.target, step.coord)
+		if step.err != "" {
+			if err == nil || err.Error() != step.err {
+				t.Fatalf("step %d: expected %s, got %v", i, step.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+
+		game, err = gm.GetGame(game.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if step.turn == "" {
+			break
+		}
+		if game.Status != models.GameStatusInProgress || game.Turn != step.turn {
+			t.Fatalf("step %d: expected the turn of %s, got %s and the turn of %s", i, step.turn, game.Status, game.Turn)
+		}
+	}
+
+	carol := game.Players["carol"]
+	if !carol.Eliminated || carol.Place != 3 {
+		t.Fatalf("expected carol to be eliminated in third place, got %+v", carol)
+	}
+	alice := game.Players["alice"]
+	if !alice.Eliminated || alice.Place != 2 {
+		t.Fatalf("expected alice to be eliminated in second place, got %+v", alice)
+	}
+	if game.Status != models.GameStatusEnd || game.Winner == nil || *game.Winner != "bob" {
+		t.Fatalf("expected bob to win, got %s and winner %v", game.Status, game.Winner)
+	}
+	if ranking := fmt.Sprint(game.Ranking); ranking != "[bob alice carol]" {
+		t.Fatalf("expected the ranking [bob alice carol], got %s", ranking)
+	}
+}
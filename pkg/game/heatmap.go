@@ -0,0 +1,143 @@
+package game
+
+import (
+	"errors"
+
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+// Heatmap counts, for each cell of a blind board, how many placements (in
+// every shape) of the plant types that can still be standing cover it without
+// overlapping a miss or a destroyed plant. When the board has hits on plants
+// still standing (partial damage games), only the placements covering those
+// hits are counted. Plants bigger than the remaining capacity are left out.
+func Heatmap(board *models.Board, size int, catalog *models.PlantCatalog) *models.Heatmap {
+	// Mark the cells no plant left can cover, and the hits on plants still
+	// standing
+	blocked := markCells(size, board.Misses, board.Destroyed)
+	openHits := board.OpenHits()
+	open := markCells(size, openHits)
+
+	heatmap := &models.Heatmap{
+		Size:   size,
+		Cells:  make([][]int, size),
+		Hits:   board.Hits,
+		Misses: board.Misses,
+	}
+	for i := range heatmap.Cells {
+		heatmap.Cells[i] = make([]int, size)
+	}
+	// Placements covering hits on plants still standing are counted apart
+	targeted := make([][]int, size)
+	for i := range targeted {
+		targeted[i] = make([]int, size)
+	}
+
+	for _, plant := range catalog.Plants {
+		// A damaged plant has lost part of its capacity already
+		if plant.Capacity > board.Capacity && len(openHits) == 0 {
+			continue
+		}
+
+		for _, shape := range plant.Shapes() {
+			for y := 0; y < size; y++ {
+				for x := 0; x < size; x++ {
+					if !isFree(blocked, size, y, x, shape.Cells) {
+						continue
+					}
+					covers := false
+					for _, cell := range shape.Cells {
+						covers = covers || open[y+cell[0]][x+cell[1]]
+					}
+					for _, cell := range shape.Cells {
+						heatmap.Cells[y+cell[0]][x+cell[1]]++
+						if covers {
+							targeted[y+cell[0]][x+cell[1]]++
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// Hits cannot be struck again
+	anyTargeted := false
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if open[y][x] {
+				heatmap.Cells[y][x] = 0
+				targeted[y][x] = 0
+			}
+			anyTargeted = anyTargeted || targeted[y][x] > 0
+		}
+	}
+	if anyTargeted {
+		heatmap.Cells = targeted
+	}
+
+	// Find the highest density to scale the map
+	for _, row := range heatmap.Cells {
+		for _, count := range row {
+			if count > heatmap.Max {
+				heatmap.Max = count
+			}
+		}
+	}
+
+	return heatmap
+}
+
+// isFree checks that the cells of a shape at (y, x) are on the board and not
+// marked
+func isFree(marked [][]bool, size int, y int, x int, cells [][2]int) bool {
+	for _, cell := range cells {
+		cy, cx := y+cell[0], x+cell[1]
+		if cy >= size || cx >= size || marked[cy][cx] {
+			return false
+		}
+	}
+	return true
+}
+
+// markCells creates a size x size grid marking the given cells
+func markCells(size int, coordLists ...[]string) [][]bool {
+	grid := make([][]bool, size)
+	for i := range grid {
+		grid[i] = make([]bool, size)
+	}
+
+	for _, coords := range coordLists {
+		for _, coord := range coords {
+			y, x, err := models.ParseCoordinate(coord)
+			if err != nil || y < 0 || y >= size || x < 0 || x >= size {
+				continue
+			}
+			grid[y][x] = true
+		}
+	}
+
+	return grid
+}
+
+// GetOpponentHeatmap computes the strike heatmap of an opponent's blind board.
+// It is only available in training games.
+func (gm *GameManager) GetOpponentHeatmap(gameID string, opponentName string) (*models.Heatmap, error) {
+	// Get the game
+	game, err := gm.store.Get(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Coaching tools are only allowed in training games
+	if !game.Training {
+		return nil, errors.New("TRAINING_ONLY")
+	}
+
+	// Check if the opponent exists
+	opponentInfo, exists := game.Players[opponentName]
+	if !exists {
+		return nil, errors.New("opponent not found")
+	}
+
+	return Heatmap(opponentInfo.Board.GenerateBlindBoard(), game.Size, game.PlantCatalog()), nil
+}
@@ -0,0 +1,73 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+// heatmapCatalog has a 2x1 plant, laid in 2 shapes, and a 1x1 plant
+var heatmapCatalog = &models.PlantCatalog{
+	Name: "heatmap",
+	Plants: []models.PlantSpec{
+		{Type: models.PlantTypeWind, Capacity: 100, Width: 2, Height: 1},
+		{Type: models.PlantTypeSolar, Capacity: 25, Width: 1, Height: 1},
+	},
+}
+
+func TestHeatmap(t *testing.T) {
+	tests := []struct {
+		name  string
+		board models.Board
+		cells [][]int
+		max   int
+	}{
+		{
+			// Each cell gets 1 SOLAR placement, and 1 or 2 WIND placements
+			// per direction
+			name:  "empty board",
+			board: models.Board{Capacity: 200},
+			cells: [][]int{{3, 4, 3}, {4, 5, 4}, {3, 4, 3}},
+			max:   5,
+		},
+		{
+			name:  "plants bigger than the capacity are left out",
+			board: models.Board{Capacity: 50},
+			cells: [][]int{{1, 1, 1}, {1, 1, 1}, {1, 1, 1}},
+			max:   1,
+		},
+		{
+			name:  "misses block placements",
+			board: models.Board{Capacity: 200, Misses: []string{"B2"}},
+			cells: [][]int{{3, 3, 3}, {3, 0, 3}, {3, 3, 3}},
+			max:   3,
+		},
+		{
+			name:  "destroyed plants block placements",
+			board: models.Board{Capacity: 200, Hits: []string{"A1"}, Destroyed: []string{"A1"}},
+			cells: [][]int{{0, 3, 3}, {3, 5, 4}, {3, 4, 3}},
+			max:   5,
+		},
+		{
+			// Only the WIND placements covering the hit count, whatever
+			// the capacity left
+			name:  "open hits are targeted",
+			board: models.Board{Capacity: 50, Hits: []string{"A1"}},
+			cells: [][]int{{0, 1, 0}, {1, 0, 0}, {0, 0, 0}},
+			max:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			heatmap := Heatmap(&tt.board, 3, heatmapCatalog)
+			if !reflect.DeepEqual(heatmap.Cells, tt.cells) {
+				t.Fatalf("expected cells %v, got %v", tt.cells, heatmap.Cells)
+			}
+			if heatmap.Max != tt.max {
+				t.Fatalf("expected max %d, got %d", tt.max, heatmap.Max)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package game
+
+import (
+	"sync"
+	"time"
+
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+// subscriptionBuffer is the number of events a subscriber can fall behind
+// before it is dropped
+const subscriptionBuffer = 64
+
+// hubPollInterval is how often the hub reads the games with subscribers from
+// the store, to pick up the changes made by other replicas
+const hubPollInterval = time.Second
+
+// Hub broadcasts the events recorded in each game to its subscribers.
+// Changes made through this process are published right away. Changes made
+// by other replicas sharing the store are picked up by reading the games
+// with subscribers every hubPollInterval.
+type Hub struct {
+	// load reads the event log of a game from the store
+	load        func(gameID string) ([]models.Event, error)
+	subscribers map[string]map[*Subscription]struct{}
+	// seqs is the last event sent to the subscribers of each game, stops
+	// ends the polling of each game
+	seqs  map[string]int
+	stops map[string]chan struct{}
+	mutex sync.Mutex
+}
+
+// Subscription receives the events of a game until it is closed.
+// Events is closed when the subscription ends, either by calling Close or
+// because the subscriber fell too far behind.
+type Subscription struct {
+	Events <-chan models.Event
+
+	events chan models.Event
+	gameID string
+	hub    *Hub
+	once   sync.Once
+}
+
+// NewHub creates a new hub reading the event logs of the games with load
+func NewHub(load func(gameID string) ([]models.Event, error)) *Hub {
+	return &Hub{
+		load:        load,
+		subscribers: make(map[string]map[*Subscription]struct{}),
+		seqs:        make(map[string]int),
+		stops:       make(map[string]chan struct{}),
+	}
+}
+
+// Subscribe starts receiving the events of a game
+func (h *Hub) Subscribe(gameID string) *Subscription {
+	events := make(chan models.Event, subscriptionBuffer)
+	sub := &Subscription{
+		Events: events,
+		events: events,
+		gameID: gameID,
+		hub:    h,
+	}
+
+	h.mutex.Lock()
+	if h.subscribers[gameID] == nil {
+		h.subscribers[gameID] = make(map[*Subscription]struct{})
+		stop := make(chan struct{})
+		h.stops[gameID] = stop
+		go h.poll(gameID, stop)
+	}
+	h.subscribers[gameID][sub] = struct{}{}
+	h.mutex.Unlock()
+
+	// Find out where the log is, so only the events recorded from now on
+	// are sent
+	h.sync(gameID)
+
+	return sub
+}
+
+// poll publishes the changes of a game made by other replicas until stop is
+// closed
+func (h *Hub) poll(gameID string, stop <-chan struct{}) {
+	ticker := time.NewTicker(hubPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			h.sync(gameID)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// sync reads the event log of a game and publishes the events not sent yet
+func (h *Hub) sync(gameID string) {
+	events, err := h.load(gameID)
+	if err != nil {
+		return
+	}
+	h.Publish(gameID, events)
+}
+
+// Close stops the subscription
+func (s *Subscription) Close() {
+	s.hub.mutex.Lock()
+	defer s.hub.mutex.Unlock()
+
+	s.close()
+}
+
+// close removes the subscription from the hub, and stops following the game
+// once it has no subscribers left. The hub mutex must be held.
+func (s *Subscription) close() {
+	s.once.Do(func() {
+		delete(s.hub.subscribers[s.gameID], s)
+		if len(s.hub.subscribers[s.gameID]) == 0 {
+			delete(s.hub.subscribers, s.gameID)
+			delete(s.hub.seqs, s.gameID)
+			if stop, exists := s.hub.stops[s.gameID]; exists {
+				close(stop)
+				delete(s.hub.stops, s.gameID)
+			}
+		}
+		close(s.events)
+	})
+}
+
+// Publish sends the events of a game log that were not sent yet to every
+// subscriber of the game. The first log published after the game gets
+// subscribers only marks where the subscribers start.
+func (h *Hub) Publish(gameID string, log []models.Event) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if len(h.subscribers[gameID]) == 0 {
+		return
+	}
+
+	last := 0
+	if len(log) > 0 {
+		last = log[len(log)-1].Seq
+	}
+	seq, known := h.seqs[gameID]
+	if !known || last <= seq {
+		if !known {
+			h.seqs[gameID] = last
+		}
+		return
+	}
+	h.seqs[gameID] = last
+
+	for sub := range h.subscribers[gameID] {
+		for _, event := range log {
+			if event.Seq <= seq {
+				continue
+			}
+			select {
+			case sub.events <- event:
+			default:
+				// The subscriber is not keeping up, drop it so it can
+				// reconnect and resynchronize
+				sub.close()
+			}
+			if _, active := h.subscribers[gameID][sub]; !active {
+				break
+			}
+		}
+	}
+}
+
+// Messages converts an event into the notifications sent to clients.
+// Events that would leak private information, such as boards, produce none.
+func Messages(event models.Event) []models.GameMessage {
+	var messages []models.GameMessage
+
+	switch event.Type {
+	case models.EventPlayerJoined:
+		messages = append(messages, models.GameMessage{
+			Type:   models.MessagePlayerJoined,
+			Player: event.Player,
+			Team:   event.Team,
+		})
+	case models.EventPlayerReady:
+		messages = append(messages, models.GameMessage{
+			Type:   models.MessagePlayerReady,
+			Player: event.Player,
+		})
+		// The last player getting ready starts the game
+		if event.Status == models.GameStatusInProgress {
+			messages = append(messages,
+				models.GameMessage{Type: models.MessageGameStarted, Turn: event.Turn},
+				models.GameMessage{Type: models.MessageTurnChange, Turn: event.Turn},
+			)
+		}
+	case models.EventStrikeResolved:
+		messages = append(messages, models.GameMessage{
+			Type:       models.MessageStrikeResult,
+			Player:     event.Player,
+			Target:     event.Target,
+			Coordinate: event.Coord,
+			Result:     event.Result,
+		})
+		if event.Status == models.GameStatusInProgress {
+			messages = append(messages, models.GameMessage{
+				Type: models.MessageTurnChange,
+				Turn: event.Turn,
+			})
+		}
+	case models.EventTurnSkipped, models.EventPlayerForfeit:
+		messageType := models.MessageTurnSkipped
+		if event.Type == models.EventPlayerForfeit {
+			messageType = models.MessageForfeit
+		}
+		messages = append(messages, models.GameMessage{
+			Type:   messageType,
+			Player: event.Player,
+		})
+		if event.Status == models.GameStatusInProgress {
+			messages = append(messages, models.GameMessage{
+				Type: models.MessageTurnChange,
+				Turn: event.Turn,
+			})
+		}
+	case models.EventGameEnded:
+		messages = append(messages, models.GameMessage{
+			Type:   models.MessageGameOver,
+			Winner: event.Winner,
+			Team:   event.Team,
+		})
+	}
+
+	for i := range messages {
+		messages[i].Seq = event.Seq
+	}
+
+	return messages
+}
@@ -0,0 +1,55 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
+)
+
+func TestSubscriptionReceivesChangesFromOtherReplicas(t *testing.T) {
+	// Two managers sharing a store act as two replicas
+	gameStore := store.NewMemoryStore()
+	local := NewGameManager(gameStore)
+	remote := NewGameManager(gameStore)
+
+	game, err := local.CreateGame(testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub := local.Subscribe(game.ID)
+	defer sub.Close()
+
+	// The remote join arrives once the hub reads the store, the local one
+	// right away, and each only once
+	if _, err := remote.JoinGame(game.ID, "alice", 0); err != nil {
+		t.Fatal(err)
+	}
+	expectJoined(t, sub, "alice")
+	if _, err := local.JoinGame(game.ID, "bob", 0); err != nil {
+		t.Fatal(err)
+	}
+	expectJoined(t, sub, "bob")
+
+	select {
+	case event := <-sub.Events:
+		t.Fatalf("unexpected event %s %s", event.Type, event.Player)
+	case <-time.After(2 * hubPollInterval):
+	}
+}
+
+// expectJoined waits for the event of a player joining the game
+func expectJoined(t *testing.T, sub *Subscription, player string) {
+	t.Helper()
+
+	select {
+	case event := <-sub.Events:
+		if event.Type != models.EventPlayerJoined || event.Player != player {
+			t.Fatalf("expected %s to join, got %s %s", player, event.Type, event.Player)
+		}
+	case <-time.After(3 * hubPollInterval):
+		t.Fatalf("%s joining was not received", player)
+	}
+}
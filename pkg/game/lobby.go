@@ -0,0 +1,66 @@
+package game
+
+import (
+	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
+)
+
+// Page sizes of the lobby
+const (
+	defaultLobbyLimit = 20
+	maxLobbyLimit     = 100
+)
+
+// ListGames returns a page of the public games matching the filter, oldest
+// first, and the cursor of the next page (empty on the last page)
+func (gm *GameManager) ListGames(filter models.LobbyFilter) ([]models.LobbyGame, string, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultLobbyLimit
+	}
+	if limit > maxLobbyLimit {
+		limit = maxLobbyLimit
+	}
+
+	query := store.GameQuery{
+		Public: true,
+		Status: filter.Status,
+		Size:   filter.Size,
+		Cursor: filter.Cursor,
+		Limit:  limit,
+	}
+	// Seats are only open until the game starts
+	if filter.HasSeat {
+		query.MaxPlayers = MaxPlayers
+	}
+
+	games, next, err := gm.store.ListGames(query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	lobby := make([]models.LobbyGame, 0, len(games))
+	for _, game := range games {
+		lobby = append(lobby, lobbyGame(game))
+	}
+
+	return lobby, next, nil
+}
+
+// lobbyGame summarizes a game for the lobby
+func lobbyGame(game *models.Game) models.LobbyGame {
+	// Seats are only open until the game starts
+	openSeats := 0
+	if game.Status == models.GameStatusPending {
+		openSeats = MaxPlayers - len(game.Players)
+	}
+
+	return models.LobbyGame{
+		ID:        game.ID,
+		Status:    game.Status,
+		Size:      game.Size,
+		Capacity:  game.Capacity,
+		Players:   len(game.Players),
+		OpenSeats: openSeats,
+	}
+}
@@ -0,0 +1,207 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
+)
+
+// Elo settings
+const (
+	// eloK is the largest rating change of a two player game
+	eloK = 32
+	// ratingRetries is how many times a conflicting rating update is retried
+	ratingRetries = 3
+)
+
+// FinishingOrder ranks the players of a finished game: the winner (or the
+// winning team) first, then the others still in the game by the share of
+// capacity they kept and last the players that left, by their place. Players
+// with the same share finish together.
+func FinishingOrder(game *models.Game) [][]string {
+	players := make([]string, 0, len(game.Players))
+	for name := range game.Players {
+		players = append(players, name)
+	}
+
+	// Players out of the game score below any share, a better place higher
+	share := func(name string) float64 {
+		info := game.Players[name]
+		if game.Winner != nil && *game.Winner == name {
+			return math.Inf(1)
+		}
+		if game.WinnerTeam != 0 && info.Team == game.WinnerTeam {
+			return math.Inf(1)
+		}
+		if info.Place > 0 {
+			return -float64(info.Place)
+		}
+		if info.TotalCapacity == 0 {
+			return 0
+		}
+		return float64(info.Capacity) / float64(info.TotalCapacity)
+	}
+	sort.Slice(players, func(i, j int) bool {
+		if share(players[i]) != share(players[j]) {
+			return share(players[i]) > share(players[j])
+		}
+		return players[i] < players[j]
+	})
+
+	var order [][]string
+	for i, name := range players {
+		if i > 0 && share(name) == share(players[i-1]) {
+			order[len(order)-1] = append(order[len(order)-1], name)
+			continue
+		}
+		order = append(order, []string{name})
+	}
+
+	return order
+}
+
+// elo computes the new ratings after a game. Every pair of players is scored
+// as a two player game by finishing order, and the changes are scaled so a
+// game with more players does not move ratings more than a duel.
+func elo(ratings map[string]float64, order [][]string) map[string]float64 {
+	place := make(map[string]int)
+	for i, group := range order {
+		for _, name := range group {
+			if _, rated := ratings[name]; rated {
+				place[name] = i
+			}
+		}
+	}
+	if len(place) < 2 {
+		return ratings
+	}
+
+	k := eloK / float64(len(place)-1)
+	updated := make(map[string]float64)
+	for name, rating := range ratings {
+		updated[name] = rating
+	}
+	for a := range place {
+		for b := range place {
+			if a == b {
+				continue
+			}
+
+			score := 0.5
+			if place[a] < place[b] {
+				score = 1
+			} else if place[a] > place[b] {
+				score = 0
+			}
+			expected := 1 / (1 + math.Pow(10, (ratings[b]-ratings[a])/400))
+			updated[a] += k * (score - expected)
+		}
+	}
+
+	return updated
+}
+
+// ratingSide returns the side a player is rated with: the team in team
+// games, the player alone otherwise
+func ratingSide(game *models.Game, name string) string {
+	if game.Teams {
+		return fmt.Sprintf("team %d", game.Players[name].Team)
+	}
+	return name
+}
+
+// won reports whether a player won a finished game, alone or with the team
+func won(game *models.Game, name string) bool {
+	if game.WinnerTeam != 0 {
+		return game.Players[name].Team == game.WinnerTeam
+	}
+	return game.Winner != nil && *game.Winner == name
+}
+
+// rateSides computes the new ratings of the accounts after a game. Sides are
+// rated against each other with the average rating of their accounts, and
+// every account gets the change of its side. Accounts without a rated
+// opponent are left out.
+func rateSides(game *models.Game, ratings map[string]float64, order [][]string) map[string]float64 {
+	// The average rating of each side with accounts
+	members := make(map[string][]string)
+	sideRatings := make(map[string]float64)
+	for name, rating := range ratings {
+		side := ratingSide(game, name)
+		members[side] = append(members[side], name)
+		sideRatings[side] += rating
+	}
+	if len(members) < 2 {
+		return nil
+	}
+	for side, names := range members {
+		sideRatings[side] /= float64(len(names))
+	}
+
+	// Sides finish with their best placed player
+	sideOrder := make([][]string, len(order))
+	placed := make(map[string]bool)
+	for i, group := range order {
+		for _, name := range group {
+			side := ratingSide(game, name)
+			if _, rated := sideRatings[side]; rated && !placed[side] {
+				sideOrder[i] = append(sideOrder[i], side)
+				placed[side] = true
+			}
+		}
+	}
+
+	updated := make(map[string]float64)
+	for side, rating := range elo(sideRatings, sideOrder) {
+		for _, name := range members[side] {
+			updated[name] = ratings[name] + rating - sideRatings[side]
+		}
+	}
+
+	return updated
+}
+
+// updateRatings updates the accounts that played a finished game
+func (gm *GameManager) updateRatings(game *models.Game) {
+	var names []string
+	for name, info := range game.Players {
+		if info.Account {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	order := FinishingOrder(game)
+
+	var err error
+	for i := 0; i < ratingRetries; i++ {
+		err = gm.store.UpdateAccounts(names, func(accounts map[string]*models.Account) error {
+			ratings := make(map[string]float64)
+			for name, account := range accounts {
+				ratings[name] = account.Rating
+			}
+
+			for name, rating := range rateSides(game, ratings, order) {
+				account := accounts[name]
+				account.Rating = rating
+				account.Games++
+				if won(game, name) {
+					account.Wins++
+				}
+			}
+			return nil
+		})
+		if !errors.Is(err, store.ErrConflict) {
+			break
+		}
+	}
+	if err != nil {
+		log.Printf("failed to update ratings of game %s: %v", game.ID, err)
+	}
+}
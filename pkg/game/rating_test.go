@@ -0,0 +1,107 @@
+package game
+
+import (
+	"math"
+	"testing"
+
+	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
+)
+
+// ratingManager creates a manager with an account for each name
+func ratingManager(t *testing.T, names ...string) (*GameManager, store.GameStore) {
+	t.Helper()
+
+	gameStore := store.NewMemoryStore()
+	for _, name := range names {
+		if err := gameStore.CreateAccount(&models.Account{Name: name, Rating: InitialRating}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return NewGameManager(gameStore), gameStore
+}
+
+// account reads an account from the store
+func account(t *testing.T, gameStore store.GameStore, name string) *models.Account {
+	t.Helper()
+
+	account, err := gameStore.GetAccount(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return account
+}
+
+func TestUpdateRatingsCreditsTheWholeWinningTeam(t *testing.T) {
+	gm, gameStore := ratingManager(t, "alice", "bob", "carol", "dave")
+
+	// alice struck last, but bob won with her
+	winner := "alice"
+	gm.updateRatings(&models.Game{
+		ID:         "teams",
+		Status:     models.GameStatusEnd,
+		Teams:      true,
+		Winner:     &winner,
+		WinnerTeam: 1,
+		Players: map[string]models.PlayerInfo{
+			"alice": {Account: true, Team: 1, Capacity: 200, TotalCapacity: 1000},
+			"bob":   {Account: true, Team: 1, Eliminated: true, Place: 3},
+			"carol": {Account: true, Team: 2, Eliminated: true, Place: 2},
+			"dave":  {Account: true, Team: 2, Eliminated: true, Place: 1},
+		},
+	})
+
+	alice := account(t, gameStore, "alice")
+	bob := account(t, gameStore, "bob")
+	carol := account(t, gameStore, "carol")
+	dave := account(t, gameStore, "dave")
+
+	for _, winner := range []*models.Account{alice, bob} {
+		if winner.Games != 1 || winner.Wins != 1 {
+			t.Fatalf("expected %s to have 1 game and 1 win, got %d and %d", winner.Name, winner.Games, winner.Wins)
+		}
+	}
+	for _, loser := range []*models.Account{carol, dave} {
+		if loser.Games != 1 || loser.Wins != 0 {
+			t.Fatalf("expected %s to have 1 game and no win, got %d and %d", loser.Name, loser.Games, loser.Wins)
+		}
+	}
+
+	// Equal teams win and lose half of a duel
+	gain := alice.Rating - InitialRating
+	if math.Abs(gain-eloK/2) > 1e-9 {
+		t.Fatalf("expected the winners to gain %v, got %v", float64(eloK)/2, gain)
+	}
+	want := map[*models.Account]float64{
+		bob:   InitialRating + gain,
+		carol: InitialRating - gain,
+		dave:  InitialRating - gain,
+	}
+	for a, rating := range want {
+		if math.Abs(a.Rating-rating) > 1e-9 {
+			t.Fatalf("expected %s to be rated %v, got %v", a.Name, rating, a.Rating)
+		}
+	}
+}
+
+func TestUpdateRatingsNeedsARatedOpponent(t *testing.T) {
+	gm, gameStore := ratingManager(t, "alice")
+
+	// bob is a guest
+	winner := "alice"
+	gm.updateRatings(&models.Game{
+		ID:     "guest",
+		Status: models.GameStatusEnd,
+		Winner: &winner,
+		Players: map[string]models.PlayerInfo{
+			"alice": {Account: true},
+			"bob":   {},
+		},
+	})
+
+	alice := account(t, gameStore, "alice")
+	if alice.Games != 0 || alice.Wins != 0 || alice.Rating != InitialRating {
+		t.Fatalf("expected alice to be untouched, got %d games, %d wins and rating %v", alice.Games, alice.Wins, alice.Rating)
+	}
+}
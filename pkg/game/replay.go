@@ -0,0 +1,167 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+// ErrGameNotFinished is returned when asking for the replay of a game that
+// has not ended
+var ErrGameNotFinished = errors.New("GAME_NOT_FINISHED")
+
+// ErrReplayNotAllowed is returned when somebody who does not play a private
+// game asks for its replay, as its boards are only shown to its players
+var ErrReplayNotAllowed = errors.New("REPLAY_NOT_ALLOWED")
+
+// ErrInvalidStep is returned when asking for a replay step outside the game
+var ErrInvalidStep = errors.New("INVALID_STEP")
+
+// ReplayMoves lists the strikes recorded in an event log, in order, with the
+// target's capacity after each strike
+func ReplayMoves(events []models.Event) ([]models.ReplayMove, error) {
+	moves := []models.ReplayMove{}
+	if len(events) == 0 {
+		return moves, nil
+	}
+
+	// Replay the log step by step to know the capacity after each strike
+	game, err := Replay(events[:1])
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events[1:] {
+		event, err := applyEvent(game, event)
+		if err != nil {
+			return nil, fmt.Errorf("replaying event %d (%s): %w", event.Seq, event.Type, err)
+		}
+		if event.Type != models.EventStrikeResolved {
+			continue
+		}
+
+		moves = append(moves, models.ReplayMove{
+			Move:       len(moves) + 1,
+			Attacker:   event.Player,
+			Target:     event.Target,
+			Coordinate: event.Coord,
+			Result:     event.Result,
+			Capacity:   game.Players[event.Target].Capacity,
+		})
+	}
+
+	return moves, nil
+}
+
+// ReplayStep rebuilds the game as it was right after the given move.
+// Step 0 is the game as it was when all players were ready.
+func ReplayStep(events []models.Event, step int) (*models.ReplayStep, error) {
+	moves, err := ReplayMoves(events)
+	if err != nil {
+		return nil, err
+	}
+	if step < 0 || step > len(moves) {
+		return nil, ErrInvalidStep
+	}
+
+	game, err := Replay(events[:movesEnd(events, step)])
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ReplayStep{
+		Step:   step,
+		Moves:  len(moves),
+		Size:   game.Size,
+		Status: game.Status,
+		Turn:   game.Turn,
+		Winner: game.Winner,
+		Boards: make(map[string]*models.Board),
+	}
+	if step > 0 {
+		result.Move = &moves[step-1]
+	}
+	for name, info := range game.Players {
+		result.Boards[name] = info.Board
+	}
+
+	return result, nil
+}
+
+// GetReplay lists the moves of a finished game. Private games are only
+// replayed for their players, which the caller tells with player.
+func (gm *GameManager) GetReplay(gameID string, player bool) ([]models.ReplayMove, error) {
+	game, err := gm.finishedGame(gameID, player)
+	if err != nil {
+		return nil, err
+	}
+
+	return ReplayMoves(game.Events)
+}
+
+// GetReplayStep retrieves a finished game as it was after the given move.
+// Private games are only replayed for their players, which the caller tells
+// with player.
+func (gm *GameManager) GetReplayStep(gameID string, step int, player bool) (*models.ReplayStep, error) {
+	game, err := gm.finishedGame(gameID, player)
+	if err != nil {
+		return nil, err
+	}
+
+	return ReplayStep(game.Events, step)
+}
+
+// movesEnd returns the length of the log prefix that contains the first
+// moves strikes and nothing after them
+func movesEnd(events []models.Event, moves int) int {
+	strikes := 0
+	for i, event := range events {
+		if event.Type != models.EventStrikeResolved {
+			continue
+		}
+		if strikes == moves {
+			return i
+		}
+		strikes++
+	}
+
+	return len(events)
+}
+
+// Delayed rebuilds the game as it was the given number of moves ago, and
+// returns it with the number of moves it includes
+func Delayed(events []models.Event, delay int) (*models.Game, int, error) {
+	moves := 0
+	for _, event := range events {
+		if event.Type == models.EventStrikeResolved {
+			moves++
+		}
+	}
+
+	shown := moves - delay
+	if shown < 0 {
+		shown = 0
+	}
+
+	game, err := Replay(events[:movesEnd(events, shown)])
+	return game, shown, err
+}
+
+// finishedGame retrieves a game, making sure it has ended so replays cannot
+// be used to peek at the boards of a running game, and that it is public or
+// asked for by one of its players so they cannot be used to see the boards
+// of somebody else's private game
+func (gm *GameManager) finishedGame(gameID string, player bool) (*models.Game, error) {
+	game, err := gm.store.Get(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if !game.Public && !player {
+		return nil, ErrReplayNotAllowed
+	}
+	if game.Status != models.GameStatusEnd {
+		return nil, ErrGameNotFinished
+	}
+
+	return game, nil
+}
@@ -0,0 +1,113 @@
+package game
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+// Team settings
+const (
+	// teamCount is the number of teams in a team game
+	teamCount = 2
+	// teamSize is the number of players of each team
+	teamSize = 2
+)
+
+// assignTeam returns the team a joining player plays in: the requested one,
+// or the smallest team when none is requested. Games without teams have no
+// team (0).
+func assignTeam(game *models.Game, team int) (int, error) {
+	if !game.Teams {
+		if team != 0 {
+			return 0, errors.New("INVALID_TEAM")
+		}
+		return 0, nil
+	}
+
+	sizes := make(map[int]int)
+	for _, info := range game.Players {
+		sizes[info.Team]++
+	}
+
+	if team == 0 {
+		team = 1
+		for t := 2; t <= teamCount; t++ {
+			if sizes[t] < sizes[team] {
+				team = t
+			}
+		}
+	}
+	if team < 1 || team > teamCount {
+		return 0, errors.New("INVALID_TEAM")
+	}
+	if sizes[team] >= teamSize {
+		return 0, errors.New("TEAM_FULL")
+	}
+
+	return team, nil
+}
+
+// nextTeamTurn returns the player of the other team playing after the given
+// one. Each team cycles through its players still in the game, so teammates
+// take turns in alphabetical order.
+func nextTeamTurn(game *models.Game, playerName string) string {
+	team := game.Players[playerName].Team
+
+	// The players of the other teams still in the game
+	var players []string
+	for name, info := range game.Players {
+		if info.Team != team && info.InGame() {
+			players = append(players, name)
+		}
+	}
+	if len(players) == 0 {
+		return playerName
+	}
+	sort.Strings(players)
+
+	// The player after the last one of those teams to have had a turn
+	for i := len(game.Events) - 1; i >= 0; i-- {
+		event := game.Events[i]
+		switch event.Type {
+		case models.EventStrikeResolved, models.EventTurnSkipped, models.EventPlayerForfeit:
+		default:
+			continue
+		}
+		if info, exists := game.Players[event.Player]; !exists || info.Team == team {
+			continue
+		}
+		for _, name := range players {
+			if name > event.Player {
+				return name
+			}
+		}
+		return players[0]
+	}
+
+	return players[0]
+}
+
+// GetTeammateBoard retrieves the board of a player's teammate
+func (gm *GameManager) GetTeammateBoard(gameID string, playerName string, teammateName string) (*models.Board, error) {
+	// Get the game
+	game, err := gm.store.Get(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	playerInfo, exists := game.Players[playerName]
+	if !exists {
+		return nil, errors.New("player not found")
+	}
+	teammateInfo, exists := game.Players[teammateName]
+	if !exists {
+		return nil, errors.New("player not found")
+	}
+	if !game.Teams || playerInfo.Team != teammateInfo.Team {
+		return nil, errors.New("NOT_TEAMMATE")
+	}
+
+	return teammateInfo.Board, nil
+}
@@ -0,0 +1,169 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
+)
+
+func TestAssignTeam(t *testing.T) {
+	gm := NewGameManager(store.NewMemoryStore())
+
+	// Players that do not pick a team join the smallest one
+	game, err := gm.CreateGame(models.GameOptions{Size: 10, Capacity: 400, Teams: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"alice", "bob", "carol", "dave"} {
+		if _, err := gm.JoinGame(game.ID, name, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	game, err = gm.GetGame(game.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, team := range map[string]int{"alice": 1, "bob": 2, "carol": 1, "dave": 2} {
+		if game.Players[name].Team != team {
+			t.Fatalf("expected %s in team %d, got %d", name, team, game.Players[name].Team)
+		}
+	}
+
+	// Picking a team
+	game, err = gm.CreateGame(models.GameOptions{Size: 10, Capacity: 400, Teams: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		team int
+		err  string
+	}{
+		{name: "alice", team: 1},
+		{name: "bob", team: 1},
+		{name: "carol", team: 1, err: "TEAM_FULL"},
+		{name: "carol", team: 3, err: "INVALID_TEAM"},
+		{name: "carol", team: -1, err: "INVALID_TEAM"},
+		{name: "carol"},
+	}
+	for _, test := range tests {
+		_, err := gm.JoinGame(game.ID, test.name, test.team)
+		if test.err == "" && err != nil {
+			t.Fatalf("%s joining team %d: %v", test.name, test.team, err)
+		}
+		if test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Fatalf("%s joining team %d: expected %s, got %v", test.name, test.team, test.err, err)
+		}
+	}
+	game, err = gm.GetGame(game.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if team := game.Players["carol"].Team; team != 2 {
+		t.Fatalf("expected carol in team 2, got %d", team)
+	}
+
+	// Games without teams refuse them
+	game, err = gm.CreateGame(models.GameOptions{Size: 10, Capacity: 400})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gm.JoinGame(game.ID, "alice", 1); err == nil || err.Error() != "INVALID_TEAM" {
+		t.Fatalf("expected INVALID_TEAM, got %v", err)
+	}
+}
+
+func TestTeamGame(t *testing.T) {
+	gm := NewGameManager(store.NewMemoryStore())
+	opts := models.GameOptions{Size: 10, Capacity: 400, Teams: true}
+	// alice and carol play in team 1, bob and dave in team 2
+	game := startGame(t, gm, opts, []string{"alice", "bob", "carol", "dave"})
+	if game.Turn != "alice" {
+		t.Fatalf("expected alice to start, got %s", game.Turn)
+	}
+
+	// Each board has a GAS plant at the first two columns of its rows and a
+	// WIND plant at columns 4 and 5: losing both knocks a player out
+	type step struct {
+		player, target, coord string
+		err                   string
+		turn                  string
+	}
+	steps := []step{
+		{player: "alice", target: "carol", coord: "E1", err: "CANNOT_STRIKE_TEAMMATE"},
+		// The teams take turns
+		{player: "alice", target: "bob", coord: "C1", turn: "bob"},
+		{player: "bob", target: "alice", coord: "J1", turn: "carol"},
+		// bob is out, but dave still plays for team 2
+		{player: "carol", target: "bob", coord: "C4", turn: "dave"},
+		{player: "dave", target: "alice", coord: "J2", turn: "alice"},
+		{player: "alice", target: "dave", coord: "G1", turn: "dave"},
+		{player: "dave", target: "carol", coord: "J1", turn: "carol"},
+		// Team 2 is out
+		{player: "carol", target: "dave", coord: "G4"},
+	}
+	for i, step := range steps {
+		_, err := gm.Strike(game.ID, step.player, step.target, step.coord)
+		if step.err != "" {
+			if err == nil || err.Error() != step.err {
+				t.Fatalf("step %d: expected %s, got %v", i, step.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+
+		game, err = gm.GetGame(game.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if step.turn == "" {
+			break
+		}
+		if game.Status != models.GameStatusInProgress || game.Turn != step.turn {
+			t.Fatalf("step %d: expected the turn of %s, got %s and the turn of %s", i, step.turn, game.Status, game.Turn)
+		}
+	}
+
+	if game.Status != models.GameStatusEnd || game.WinnerTeam != 1 {
+		t.Fatalf("expected team 1 to win, got %s and team %d", game.Status, game.WinnerTeam)
+	}
+	if !game.Players["bob"].Eliminated || !game.Players["dave"].Eliminated {
+		t.Fatalf("expected bob and dave to be knocked out, got %+v and %+v", game.Players["bob"], game.Players["dave"])
+	}
+}
+
+func TestGetTeammateBoard(t *testing.T) {
+	gm := NewGameManager(store.NewMemoryStore())
+	game := startGame(t, gm, models.GameOptions{Size: 10, Capacity: 400, Teams: true}, []string{"alice", "bob", "carol", "dave"})
+
+	board, err := gm.GetTeammateBoard(game.ID, "alice", "carol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(board.Plants) != 2 || board.Plants[0].Coordinates[0] != "E1" {
+		t.Fatalf("expected the board of carol, got %+v", board)
+	}
+
+	// Players of games without teams have no teammates
+	solo := startGame(t, gm, models.GameOptions{Size: 10, Capacity: 400}, []string{"alice", "bob"})
+
+	tests := []struct {
+		game, player, teammate string
+		err                    string
+	}{
+		{game: game.ID, player: "alice", teammate: "bob", err: "NOT_TEAMMATE"},
+		{game: game.ID, player: "dave", teammate: "carol", err: "NOT_TEAMMATE"},
+		{game: game.ID, player: "alice", teammate: "erin", err: "player not found"},
+		{game: game.ID, player: "erin", teammate: "alice", err: "player not found"},
+		{game: solo.ID, player: "alice", teammate: "bob", err: "NOT_TEAMMATE"},
+	}
+
+	for _, test := range tests {
+		if _, err := gm.GetTeammateBoard(test.game, test.player, test.teammate); err == nil || err.Error() != test.err {
+			t.Fatalf("%s asking for the board of %s: expected %s, got %v", test.player, test.teammate, test.err, err)
+		}
+	}
+}
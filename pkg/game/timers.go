@@ -0,0 +1,119 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
+)
+
+// errTurnNotExpired aborts a turn skip when the turn was played (or skipped
+// by another replica) in the meantime
+var errTurnNotExpired = errors.New("turn not expired")
+
+// RunTurnTimers skips the turns that run out of time, checking every
+// interval until ctx is done. Several replicas can run it on the same store:
+// a turn is only skipped once.
+func (gm *GameManager) RunTurnTimers(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			gm.expireTurns(now)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// expireTurns skips the turns whose deadline has passed
+func (gm *GameManager) expireTurns(now time.Time) {
+	games, err := gm.store.ListExpired(now)
+	if err != nil {
+		log.Printf("failed to list games to expire turns: %v", err)
+		return
+	}
+
+	for _, game := range games {
+		err := gm.expireTurn(game.ID, game.Turn, now)
+		if err != nil && !errors.Is(err, errTurnNotExpired) && !errors.Is(err, store.ErrConflict) {
+			log.Printf("failed to expire turn of %s in game %s: %v", game.Turn, game.ID, err)
+		}
+	}
+}
+
+// expireTurn skips the turn of a player, who forfeits after too many
+// expired turns in a row
+func (gm *GameManager) expireTurn(gameID string, player string, now time.Time) error {
+	_, err := gm.update(gameID, func(game *models.Game) error {
+		// Check again now that we hold the latest version of the game
+		if game.Status != models.GameStatusInProgress || game.Turn != player ||
+			game.TurnDeadline == nil || now.Before(*game.TurnDeadline) {
+			return errTurnNotExpired
+		}
+
+		eventType := models.EventTurnSkipped
+		if game.Players[player].Timeouts+1 >= game.MaxTimeouts {
+			eventType = models.EventPlayerForfeit
+		}
+
+		_, err := record(game, models.Event{
+			Type:   eventType,
+			Time:   now.UTC(),
+			Player: player,
+		})
+		return err
+	})
+
+	return err
+}
+
+// skipTurn passes the turn of a player that ran out of time
+func skipTurn(game *models.Game, playerName string) error {
+	if game.Status != models.GameStatusInProgress {
+		return errors.New("game is not in progress")
+	}
+	if game.Turn != playerName {
+		return errors.New("NOT_YOUR_TURN")
+	}
+
+	playerInfo := game.Players[playerName]
+	playerInfo.Timeouts++
+	game.Players[playerName] = playerInfo
+
+	game.Turn = nextTurn(game, playerName)
+
+	return nil
+}
+
+// forfeit takes a player out of the game. The last player left wins.
+func forfeit(game *models.Game, playerName string) error {
+	if game.Status != models.GameStatusInProgress {
+		return errors.New("game is not in progress")
+	}
+
+	playerInfo, exists := game.Players[playerName]
+	if !exists {
+		return errors.New("player not found")
+	}
+	if !playerInfo.InGame() {
+		return errors.New("player is out of the game")
+	}
+	if game.Turn == playerName {
+		playerInfo.Timeouts++
+	}
+	playerInfo.Forfeited = true
+	game.Players[playerName] = playerInfo
+
+	leaveGame(game, playerName)
+	if game.Status == models.GameStatusInProgress && game.Turn == playerName {
+		game.Turn = nextTurn(game, playerName)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
+)
+
+// expireCurrentTurn runs the turn timers once the deadline of the current turn has
+// passed, and returns the game they leave
+func expireCurrentTurn(t *testing.T, gm *GameManager, gameID string) *models.Game {
+	t.Helper()
+
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.TurnDeadline == nil {
+		t.Fatalf("expected the turn of %s to have a deadline", game.Turn)
+	}
+
+	// Nothing happens before the deadline
+	gm.expireTurns(game.TurnDeadline.Add(-time.Second))
+	before, err := gm.GetGame(gameID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.Turn != game.Turn || len(before.Events) != len(game.Events) {
+		t.Fatalf("expected the turn of %s to go on before the deadline", game.Turn)
+	}
+
+	gm.expireTurns(game.TurnDeadline.Add(time.Second))
+	game, err = gm.GetGame(gameID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return game
+}
+
+func TestExpiredTurnsAreSkipped(t *testing.T) {
+	gm := NewGameManager(store.NewMemoryStore())
+	opts := models.GameOptions{Size: 10, Capacity: 400, TurnTimeout: 60, MaxTimeouts: 2}
+	game := startGame(t, gm, opts, []string{"alice", "bob", "carol"})
+	if game.Turn != "alice" {
+		t.Fatalf("expected alice to start, got %s", game.Turn)
+	}
+
+	// Each expired turn passes to the next player
+	for _, next := range []string{"bob", "carol", "alice"} {
+		previous := game.Turn
+		game = expireCurrentTurn(t, gm, game.ID)
+		if game.Turn != next {
+			t.Fatalf("expected the turn to pass from %s to %s, got %s", previous, next, game.Turn)
+		}
+		if info := game.Players[previous]; info.Timeouts != 1 || info.Forfeited {
+			t.Fatalf("expected %s to have 1 expired turn, got %+v", previous, info)
+		}
+		if last := game.Events[len(game.Events)-1]; last.Type != models.EventTurnSkipped || last.Player != previous {
+			t.Fatalf("expected the turn of %s to be skipped, got %s of %s", previous, last.Type, last.Player)
+		}
+	}
+
+	// alice misses a second turn in a row and forfeits, the others play on
+	game = expireCurrentTurn(t, gm, game.ID)
+	alice := game.Players["alice"]
+	if !alice.Forfeited || alice.Timeouts != 2 || alice.Place != 3 {
+		t.Fatalf("expected alice to forfeit in third place, got %+v", alice)
+	}
+	if game.Status != models.GameStatusInProgress || game.Turn != "bob" {
+		t.Fatalf("expected the game to go on with bob, got %s and the turn of %s", game.Status, game.Turn)
+	}
+
+	// Playing resets the count of bob, and the turns skip alice from now on
+	if _, err := gm.Strike(game.ID, "bob", "carol", "J10"); err != nil {
+		t.Fatal(err)
+	}
+	game, err := gm.GetGame(game.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.Turn != "carol" || game.Players["bob"].Timeouts != 0 {
+		t.Fatalf("expected the turn to pass to carol and bob to have no expired turn, got %s and %+v", game.Turn, game.Players["bob"])
+	}
+
+	// carol misses a second turn in a row and bob is the last player left
+	game = expireCurrentTurn(t, gm, game.ID)
+	if !game.Players["carol"].Forfeited || game.Status != models.GameStatusEnd || game.Winner == nil || *game.Winner != "bob" {
+		t.Fatalf("expected carol to forfeit and bob to win, got %s and winner %v", game.Status, game.Winner)
+	}
+}
+
+func TestForfeitEndsTwoPlayerGame(t *testing.T) {
+	gm := NewGameManager(store.NewMemoryStore())
+	opts := models.GameOptions{Size: 10, Capacity: 400, TurnTimeout: 60, MaxTimeouts: 1}
+	game := startGame(t, gm, opts, []string{"alice", "bob"})
+
+	game = expireCurrentTurn(t, gm, game.ID)
+	if !game.Players["alice"].Forfeited {
+		t.Fatalf("expected alice to forfeit, got %+v", game.Players["alice"])
+	}
+	if game.Status != models.GameStatusEnd || game.Winner == nil || *game.Winner != "bob" {
+		t.Fatalf("expected bob to win, got %s and winner %v", game.Status, game.Winner)
+	}
+	if game.TurnDeadline != nil {
+		t.Fatalf("expected no deadline once the game ended, got %v", game.TurnDeadline)
+	}
+}
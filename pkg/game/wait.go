@@ -0,0 +1,132 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+// waitPollInterval is how often WaitForTurn reads the game again, to notice
+// the changes made by other replicas
+const waitPollInterval = time.Second
+
+// signals wakes up goroutines waiting for a game to change.
+// Each game has a channel that is closed (and replaced) on its next change.
+type signals struct {
+	channels map[string]chan struct{}
+	mutex    sync.Mutex
+}
+
+// changed returns a channel that is closed the next time the game changes
+func (s *signals) changed(gameID string) <-chan struct{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.channels == nil {
+		s.channels = make(map[string]chan struct{})
+	}
+	ch, exists := s.channels[gameID]
+	if !exists {
+		ch = make(chan struct{})
+		s.channels[gameID] = ch
+	}
+
+	return ch
+}
+
+// signal wakes up everyone waiting for the game to change
+func (s *signals) signal(gameID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if ch, exists := s.channels[gameID]; exists {
+		close(ch)
+		delete(s.channels, gameID)
+	}
+}
+
+// WaitForTurn blocks until it is the player's turn or the game has ended, or
+// until ctx is done. It returns the game as it was when it stopped waiting,
+// together with ctx's error if it timed out. Changes made through this
+// process wake up waiters right away, the ones made by other replicas within
+// waitPollInterval.
+func (gm *GameManager) WaitForTurn(ctx context.Context, gameID string, playerName string) (*models.Game, error) {
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Get the signal before reading the game so no change is missed
+		changed := gm.signals.changed(gameID)
+
+		game, err := gm.store.Get(gameID)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := game.Players[playerName]; !exists {
+			return nil, errors.New("player not found")
+		}
+
+		if game.Status == models.GameStatusEnd ||
+			(game.Status == models.GameStatusInProgress && game.Turn == playerName) {
+			return game, nil
+		}
+
+		select {
+		case <-changed:
+		case <-ticker.C:
+		case <-ctx.Done():
+			return game, ctx.Err()
+		}
+	}
+}
+
+// GameContext returns a copy of ctx that is cancelled once the game ends or
+// is deleted, whether through this process or another replica
+func (gm *GameManager) GameContext(ctx context.Context, gameID string) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		defer cancel()
+
+		ticker := time.NewTicker(waitPollInterval)
+		defer ticker.Stop()
+
+		for {
+			changed := gm.signals.changed(gameID)
+
+			game, err := gm.store.Get(gameID)
+			if err != nil || game.Status == models.GameStatusEnd {
+				return
+			}
+
+			select {
+			case <-changed:
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// LastStrikeAgainst returns the latest strike against a player, or nil if the
+// player has not been struck yet
+func LastStrikeAgainst(game *models.Game, playerName string) *models.StrikeInfo {
+	for i := len(game.Events) - 1; i >= 0; i-- {
+		event := game.Events[i]
+		if event.Type == models.EventStrikeResolved && event.Target == playerName {
+			return &models.StrikeInfo{
+				Attacker:   event.Player,
+				Coordinate: event.Coord,
+				Result:     event.Result,
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
+)
+
+func TestWaitForTurnSeesChangesFromOtherReplicas(t *testing.T) {
+	gameStore := store.NewMemoryStore()
+	gm := NewGameManager(gameStore)
+
+	game, err := gm.CreateGame(testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gm.JoinGame(game.ID, "alice", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another replica ends the game straight in the store, which does not
+	// signal this manager
+	go func() {
+		time.Sleep(waitPollInterval / 2)
+		gameStore.Update(game.ID, func(game *models.Game) error {
+			game.Status = models.GameStatusEnd
+			return nil
+		})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*waitPollInterval)
+	defer cancel()
+	game, err = gm.WaitForTurn(ctx, game.ID, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.Status != models.GameStatusEnd {
+		t.Fatalf("expected the game to have ended, got %s", game.Status)
+	}
+}
+
+func TestGameContextEndsWithGame(t *testing.T) {
+	gameStore := store.NewMemoryStore()
+	gm := NewGameManager(gameStore)
+
+	game, err := gm.CreateGame(testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := gm.GameContext(context.Background(), game.ID)
+	defer cancel()
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("the context was cancelled while the game is running")
+	case <-time.After(waitPollInterval / 2):
+	}
+
+	// Another replica ends the game straight in the store
+	gameStore.Update(game.ID, func(game *models.Game) error {
+		game.Status = models.GameStatusEnd
+		return nil
+	})
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(3 * waitPollInterval):
+		t.Fatal("the context was not cancelled when the game ended")
+	}
+}
@@ -0,0 +1,135 @@
+package game
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"io"
+	"math/rand"
+
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+// Weather output ranges, in percent of the capacity of the plants
+const (
+	minSun  = 20
+	minWind = 10
+)
+
+// generateWeatherSeed draws the seed of the weather of a game
+func (gm *GameManager) generateWeatherSeed() (int64, error) {
+	gm.randomMutex.Lock()
+	defer gm.randomMutex.Unlock()
+
+	var b [8]byte
+	if _, err := io.ReadFull(gm.random, b[:]); err != nil {
+		return 0, err
+	}
+
+	// Keep the seed positive, 0 means no seed
+	return int64(binary.BigEndian.Uint64(b[:])>>1) | 1, nil
+}
+
+// roundSeed mixes the seed of a game with a round. Adding them would give the
+// next game seed the weather of the next round.
+func roundSeed(seed int64, round int) int64 {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(seed))
+	binary.BigEndian.PutUint64(b[8:], uint64(round))
+
+	h := fnv.New64a()
+	h.Write(b[:])
+	return int64(h.Sum64())
+}
+
+// weatherAt draws the weather of a round. Each round has its own generator,
+// so any round can be forecast without drawing the rounds before it.
+func weatherAt(seed int64, round int) *models.WeatherConditions {
+	rng := rand.New(rand.NewSource(roundSeed(seed, round)))
+
+	return &models.WeatherConditions{
+		Round: round,
+		Sun:   minSun + rng.Intn(100-minSun+1),
+		Wind:  minWind + rng.Intn(100-minWind+1),
+	}
+}
+
+// updateWeather starts the first round of weather games and moves on to the
+// next round once every player still in the game has had a turn. The output
+// of every player follows the weather of the round; only strikes check it
+// against the knockout limit, which follows the weather as well.
+func updateWeather(game *models.Game, event models.Event) {
+	if !game.Weather || game.Status != models.GameStatusInProgress {
+		return
+	}
+
+	switch event.Type {
+	case models.EventPlayerReady:
+		if game.Round > 0 {
+			return
+		}
+		game.Round = 1
+	case models.EventStrikeResolved, models.EventTurnSkipped, models.EventPlayerForfeit:
+		game.RoundTurns++
+		inGame := 0
+		for _, info := range game.Players {
+			if info.InGame() {
+				inGame++
+			}
+		}
+		if game.RoundTurns < inGame {
+			return
+		}
+		game.Round++
+		game.RoundTurns = 0
+	default:
+		return
+	}
+
+	game.Conditions = weatherAt(game.WeatherSeed, game.Round)
+	game.Forecast = weatherAt(game.WeatherSeed, game.Round+1)
+	for name, info := range game.Players {
+		info.Capacity = effectiveCapacity(game, info.Board)
+		game.Players[name] = info
+	}
+}
+
+// roundCapacity returns the output the plants of a board would make in the
+// weather of the round without any hit
+func roundCapacity(game *models.Game, board *models.Board) int {
+	if board == nil {
+		return 0
+	}
+	return effectiveCapacity(game, &models.Board{Plants: board.Plants})
+}
+
+// effectiveCapacity returns the output of the plants of a board, without the
+// capacity lost to hits, with WIND and SOLAR plants scaled by the weather of
+// the round
+func effectiveCapacity(game *models.Game, board *models.Board) int {
+	if board == nil {
+		return 0
+	}
+
+	catalog := game.PlantCatalog()
+	output := 0
+	for _, plant := range board.Plants {
+		if len(plant.Coordinates) == 0 {
+			continue
+		}
+
+		// Hit cells take their share of the capacity, all of it once the
+		// plant is destroyed
+		capacity := catalog.Capacity(plant.Type)
+		struck := 0
+		for _, coord := range plant.Coordinates {
+			if contains(board.Hits, coord) {
+				struck++
+			}
+		}
+		left := capacity - capacity*struck/len(plant.Coordinates)
+
+		output += left * game.Conditions.Output(plant.Type) / 100
+	}
+
+	return output
+}
@@ -0,0 +1,129 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+func TestWeatherOfNextSeedIsNotNextRound(t *testing.T) {
+	same := 0
+	for seed := int64(1); seed <= 50; seed++ {
+		next, shifted := weatherAt(seed, 2), weatherAt(seed+1, 1)
+		if next.Sun == shifted.Sun && next.Wind == shifted.Wind {
+			same++
+		}
+	}
+	if same == 50 {
+		t.Fatal("the next seed gives the weather of the next round")
+	}
+}
+
+// weatherGame returns a weather game at the last turn of round 1. Players
+// with a WIND plant can't make 10% of their total capacity in any weather,
+// the others have a NUCLEAR plant.
+func weatherGame(elimination bool, wind []string, nuclear []string) *models.Game {
+	game := &models.Game{
+		ID:          "weather",
+		Status:      models.GameStatusInProgress,
+		Players:     make(map[string]models.PlayerInfo),
+		Elimination: elimination,
+		Weather:     true,
+		WeatherSeed: 1,
+		Round:       1,
+		RoundTurns:  len(wind) + len(nuclear) - 1,
+	}
+
+	add := func(name string, plantType models.PlantType, total int) {
+		capacity := models.DefaultCatalog.Capacity(plantType)
+		board := &models.Board{
+			Plants:        []models.Plant{{Type: plantType, Coordinates: []string{"A1"}}},
+			TotalCapacity: total,
+			Capacity:      capacity,
+		}
+		game.Players[name] = models.PlayerInfo{
+			Ready:         true,
+			TotalCapacity: total,
+			Capacity:      capacity,
+			Board:         board,
+		}
+	}
+	for _, name := range wind {
+		add(name, models.PlantTypeWind, 20*models.DefaultCatalog.Capacity(models.PlantTypeWind))
+	}
+	for _, name := range nuclear {
+		add(name, models.PlantTypeNuclear, models.DefaultCatalog.Capacity(models.PlantTypeNuclear))
+	}
+
+	return game
+}
+
+func TestWeatherRoundDoesNotKnockOut(t *testing.T) {
+	for _, elimination := range []bool{false, true} {
+		game := weatherGame(elimination, []string{"alice"}, []string{"bob"})
+		game.Turn = "bob"
+
+		updateWeather(game, models.Event{Type: models.EventTurnSkipped, Player: "alice"})
+
+		if game.Round != 2 {
+			t.Fatalf("expected round 2, got %d", game.Round)
+		}
+		// alice makes less than 10% of the total capacity but stays in the game
+		alice := game.Players["alice"]
+		if alice.Capacity > alice.TotalCapacity/10 || alice.Capacity != effectiveCapacity(game, alice.Board) {
+			t.Fatalf("expected the output of alice to follow the weather below 10%%, got %d", alice.Capacity)
+		}
+		if game.Status != models.GameStatusInProgress || game.Winner != nil || alice.Eliminated {
+			t.Fatalf("expected the game to go on with alice (elimination %v), got %s", elimination, game.Status)
+		}
+		if game.Turn != "bob" {
+			t.Fatalf("expected the turn to stay with bob, got %s", game.Turn)
+		}
+	}
+}
+
+func TestCalmRoundKnockoutLimit(t *testing.T) {
+	// alice only has WIND plants, which make 10% of their capacity in the
+	// calm round: 100 MW out of 1000
+	var plants []models.Plant
+	for row := byte('A'); row <= 'J'; row++ {
+		plants = append(plants, models.Plant{Type: models.PlantTypeWind, Coordinates: []string{string(row) + "1", string(row) + "2"}})
+	}
+	total := len(plants) * models.DefaultCatalog.Capacity(models.PlantTypeWind)
+	game := &models.Game{
+		ID:         "calm",
+		Size:       10,
+		Status:     models.GameStatusInProgress,
+		Players:    make(map[string]models.PlayerInfo),
+		Weather:    true,
+		Round:      1,
+		Conditions: &models.WeatherConditions{Round: 1, Sun: minSun, Wind: minWind},
+	}
+	for _, name := range []string{"alice", "bob"} {
+		board := &models.Board{Plants: plants, TotalCapacity: total, Capacity: total}
+		game.Players[name] = models.PlayerInfo{Ready: true, TotalCapacity: total, Board: board}
+	}
+	for name, info := range game.Players {
+		info.Capacity = effectiveCapacity(game, info.Board)
+		game.Players[name] = info
+	}
+
+	// Each hit takes 10 MW: alice stays in the game until losing 90% of
+	// what the plants make in this weather
+	for i, row := range "ABCDEFGHI" {
+		game.Turn = "bob"
+		if _, err := strike(game, "bob", "alice", string(row)+"1"); err != nil {
+			t.Fatal(err)
+		}
+		alice := game.Players["alice"]
+		if alice.Capacity != 100-10*(i+1) {
+			t.Fatalf("expected alice to make %d MW after %d hits, got %d", 100-10*(i+1), i+1, alice.Capacity)
+		}
+		if last := i == 8; (game.Status == models.GameStatusEnd) != last {
+			t.Fatalf("expected the game to end only after the 9th hit, got %s after hit %d", game.Status, i+1)
+		}
+	}
+	if game.Winner == nil || *game.Winner != "bob" {
+		t.Fatalf("expected bob to win, got %v", game.Winner)
+	}
+}
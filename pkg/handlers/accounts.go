@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
+)
+
+// maxLeaderboard is the largest leaderboard page
+const maxLeaderboard = 100
+
+// accountKey returns the API key sent with the request, if any
+func accountKey(c echo.Context) string {
+	if key := c.Request().Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	return c.QueryParam("key")
+}
+
+// @Summary Register a player account
+// @Description Registers a player name and returns its API key. Joining games with the key makes them count towards the player rating. The key is only shown once.
+// @Tags players
+// @Accept json
+// @Produce json
+// @Param name query string true "Player name"
+// @Success 200 {object} models.RegisterResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /players [post]
+func (h *Handler) RegisterPlayer(c echo.Context) error {
+	name := c.QueryParam("name")
+
+	key, err := h.GameManager.Register(name)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, store.ErrAccountExists) {
+			status = http.StatusConflict
+		}
+		return c.JSON(status, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.RegisterResponse{
+		Name: name,
+		Key:  key,
+	})
+}
+
+// @Summary Get a player account
+// @Description Gets the rating and statistics of a registered player
+// @Tags players
+// @Accept json
+// @Produce json
+// @Param name path string true "Player name"
+// @Success 200 {object} models.Account
+// @Failure 404 {object} models.ErrorResponse
+// @Router /players/{name} [get]
+func (h *Handler) GetPlayer(c echo.Context) error {
+	account, err := h.GameManager.GetAccount(c.Param("name"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, store.ErrAccountNotFound) {
+			status = http.StatusNotFound
+		}
+		return c.JSON(status, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, account)
+}
+
+// @Summary Get the leaderboard
+// @Description Lists the registered players with the highest rating
+// @Tags players
+// @Accept json
+// @Produce json
+// @Param limit query int false "Number of players (max 100)" default(20)
+// @Success 200 {array} models.LeaderboardEntry
+// @Failure 400 {object} models.ErrorResponse
+// @Router /leaderboard [get]
+func (h *Handler) GetLeaderboard(c echo.Context) error {
+	limit := 20
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > maxLeaderboard {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "INVALID_PARAMETERS",
+			})
+		}
+	}
+
+	entries, err := h.GameManager.Leaderboard(limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
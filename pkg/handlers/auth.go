@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/xorduna/energywar/pkg/auth"
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+// playerContextKey is where RequirePlayer stores the authenticated player
+const playerContextKey = "player"
+
+// secretParams are the query parameters carrying credentials, which must
+// never show up in logs
+var secretParams = []string{"token", "key"}
+
+// Player represents the player authenticated for a request
+type Player struct {
+	GameID string
+	Name   string
+}
+
+// bearerToken returns the token sent in the Authorization header, or in the
+// deprecated token query parameter
+func bearerToken(c echo.Context) string {
+	auth := c.Request().Header.Get(echo.HeaderAuthorization)
+	if token, found := strings.CutPrefix(auth, "Bearer "); found {
+		return strings.TrimSpace(token)
+	}
+	return c.QueryParam("token")
+}
+
+// RequirePlayer is a middleware that authenticates the player of the :id and
+// :name route parameters with its token, and stores it in the context
+func (h *Handler) RequirePlayer(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id := c.Param("id")
+		name := c.Param("name")
+
+		token := bearerToken(c)
+		if token == "" {
+			return c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "MISSING_TOKEN",
+			})
+		}
+
+		if err := h.validatePlayerToken(id, name, token); err != nil {
+			// Tell clients when they can refresh or rejoin
+			code := "INVALID_TOKEN"
+			if errors.Is(err, auth.ErrExpiredToken) {
+				code = "TOKEN_EXPIRED"
+			}
+			return c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  code,
+			})
+		}
+
+		c.Set(playerContextKey, Player{GameID: id, Name: name})
+		return next(c)
+	}
+}
+
+// currentPlayer returns the player authenticated by RequirePlayer
+func currentPlayer(c echo.Context) Player {
+	player, _ := c.Get(playerContextKey).(Player)
+	return player
+}
+
+// RedactURI hides the credentials in the query of a request URI so it can
+// be logged
+func RedactURI(uri string) string {
+	path, rawQuery, found := strings.Cut(uri, "?")
+	if !found {
+		return uri
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return path + "?REDACTED"
+	}
+	for _, param := range secretParams {
+		if query.Has(param) {
+			query.Set(param, "REDACTED")
+		}
+	}
+
+	return path + "?" + query.Encode()
+}
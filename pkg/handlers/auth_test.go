@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/xorduna/energywar/pkg/auth"
+	"github.com/xorduna/energywar/pkg/game"
+	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
+)
+
+func TestRedactURI(t *testing.T) {
+	tests := []struct {
+		uri      string
+		expected string
+	}{
+		{uri: "/api/games", expected: "/api/games"},
+		{uri: "/api/games?public=true", expected: "/api/games?public=true"},
+		{uri: "/api/games/g1/ws?token=secret", expected: "/api/games/g1/ws?token=REDACTED"},
+		{uri: "/api/games/g1/join?player=alice&key=secret", expected: "/api/games/g1/join?key=REDACTED&player=alice"},
+		{uri: "/api/games/g1?key=a&token=b&token=c", expected: "/api/games/g1?key=REDACTED&token=REDACTED"},
+		{uri: "/api/games/g1?token=", expected: "/api/games/g1?token=REDACTED"},
+		{uri: "/api/games/g1?token=secret;key=%zz", expected: "/api/games/g1?REDACTED"},
+	}
+	for _, test := range tests {
+		if redacted := RedactURI(test.uri); redacted != test.expected {
+			t.Fatalf("redacting %s: expected %s, got %s", test.uri, test.expected, redacted)
+		}
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header   string
+		query    string
+		expected string
+	}{
+		{expected: ""},
+		{header: "Bearer header-token", expected: "header-token"},
+		{header: "Bearer  header-token ", expected: "header-token"},
+		{query: "?token=query-token", expected: "query-token"},
+		{header: "Bearer header-token", query: "?token=query-token", expected: "header-token"},
+		{header: "Basic YWxpY2U6c2VjcmV0", query: "?token=query-token", expected: "query-token"},
+		{header: "Basic YWxpY2U6c2VjcmV0", expected: ""},
+	}
+
+	e := echo.New()
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/games/g1"+test.query, nil)
+		if test.header != "" {
+			req.Header.Set(echo.HeaderAuthorization, test.header)
+		}
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		if token := bearerToken(c); token != test.expected {
+			t.Fatalf("header %q and query %q: expected %q, got %q", test.header, test.query, test.expected, token)
+		}
+	}
+}
+
+func TestSignedTokenClaims(t *testing.T) {
+	gm := game.NewGameManager(store.NewMemoryStore())
+	h := NewHandler(gm)
+	h.Tokens = auth.NewSigner([]byte(strings.Repeat("k", 32)), time.Hour)
+
+	gameObj, err := gm.CreateGame(models.GameOptions{Size: 10, Capacity: 1000, Public: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherGame, err := gm.CreateGame(models.GameOptions{Size: 10, Capacity: 1000, Public: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{gameObj.ID, otherGame.ID} {
+		for _, player := range []string{"alice", "bob"} {
+			if _, err := gm.JoinGame(id, player, 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	sign := func(gameID, player string) string {
+		t.Helper()
+		token, _, err := h.Tokens.Sign(gameID, player)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name      string
+		token     string
+		player    bool
+		spectator bool
+	}{
+		{name: "player", token: sign(gameObj.ID, "alice"), player: true},
+		{name: "spectator", token: sign(gameObj.ID, ""), spectator: true},
+		{name: "other player", token: sign(gameObj.ID, "bob")},
+		{name: "other game", token: sign(otherGame.ID, "alice")},
+		{name: "spectator of other game", token: sign(otherGame.ID, "")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := h.validatePlayerToken(gameObj.ID, "alice", test.token)
+			if test.player != (err == nil) {
+				t.Fatalf("expected the token to be valid for alice: %v, got %v", test.player, err)
+			}
+			err = h.validateSpectatorToken(gameObj, test.token)
+			if test.spectator != (err == nil) {
+				t.Fatalf("expected the token to be valid for spectators: %v, got %v", test.spectator, err)
+			}
+		})
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	key := []byte(strings.Repeat("k", 32))
+	gm := game.NewGameManager(store.NewMemoryStore())
+	h := NewHandler(gm)
+	h.Tokens = auth.NewSigner(key, time.Hour)
+
+	gameObj, err := gm.CreateGame(models.GameOptions{Size: 10, Capacity: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, player := range []string{"alice", "bob"} {
+		if _, err := gm.JoinGame(gameObj.ID, player, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e := echo.New()
+	e.POST("/api/games/:id/players/:name/token", h.RequirePlayer(h.RefreshToken))
+	refresh := func(token string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/games/"+gameObj.ID+"/players/alice/token", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	sign := func(signer *auth.Signer, player string) (string, time.Time) {
+		t.Helper()
+		token, expiresAt, err := signer.Sign(gameObj.ID, player)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token, expiresAt
+	}
+
+	// A token about to expire gets a new expiry
+	token, expiresAt := sign(auth.NewSigner(key, time.Minute), "alice")
+	rec := refresh(token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var response JoinGameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.ExpiresAt == nil || !response.ExpiresAt.After(expiresAt) {
+		t.Fatalf("expected an expiry after %v, got %v", expiresAt, response.ExpiresAt)
+	}
+	claims, err := h.Tokens.Verify(response.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.GameID != gameObj.ID || claims.Player != "alice" || claims.Role != auth.RolePlayer {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+
+	// Expired and foreign tokens cannot be refreshed
+	expired, _ := sign(auth.NewSigner(key, -time.Minute), "alice")
+	foreignKey, _ := sign(auth.NewSigner([]byte(strings.Repeat("o", 32)), time.Hour), "alice")
+	otherPlayer, _ := sign(h.Tokens, "bob")
+	spectator, _ := sign(h.Tokens, "")
+	tests := []struct {
+		name     string
+		token    string
+		expected string
+	}{
+		{name: "expired", token: expired, expected: "TOKEN_EXPIRED"},
+		{name: "other secret", token: foreignKey, expected: "INVALID_TOKEN"},
+		{name: "other player", token: otherPlayer, expected: "INVALID_TOKEN"},
+		{name: "spectator", token: spectator, expected: "INVALID_TOKEN"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := refresh(test.token)
+			if rec.Code != http.StatusForbidden {
+				t.Fatalf("expected status %d, got %d", http.StatusForbidden, rec.Code)
+			}
+			var response models.ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Error != test.expected {
+				t.Fatalf("expected error %s, got %s", test.expected, response.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// @Summary List the plant catalogs
+// @Description Lists the plant catalogs games can be created with, each defining the type, capacity, size and allowed count of its plants
+// @Tags games
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.PlantCatalog
+// @Router /catalogs [get]
+func (h *Handler) ListCatalogs(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.GameManager.Catalogs())
+}
@@ -1,29 +1,65 @@
 package handlers
 
 import (
+	"context"
+	"crypto/subtle"
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/xorduna/energywar/pkg/auth"
+	"github.com/xorduna/energywar/pkg/bot"
 	"github.com/xorduna/energywar/pkg/game"
+	"github.com/xorduna/energywar/pkg/matchmaking"
 	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
 )
 
 // Handler contains all the handler functions for the API
 type Handler struct {
 	GameManager *game.GameManager
+	Matchmaking *matchmaking.Queue
+	// Tokens signs the player tokens as JWTs. When nil players get the
+	// random token stored in the game.
+	Tokens *auth.Signer
 }
 
 // NewHandler creates a new handler
 func NewHandler(gm *game.GameManager) *Handler {
 	return &Handler{
 		GameManager: gm,
+		Matchmaking: matchmaking.NewQueue(gm),
 	}
 }
 
-// validatePlayerToken checks if the provided token matches the player's token
+// validatePlayerToken checks if the provided token matches the player's token.
+// Signed tokens stop working once the game ends.
 func (h *Handler) validatePlayerToken(gameID, playerName, token string) error {
+	return h.checkPlayerToken(gameID, playerName, token, false)
+}
+
+// validatePlayerReadToken checks the token of a player that only follows the
+// game, which keeps working once the game ends
+func (h *Handler) validatePlayerReadToken(gameID, playerName, token string) error {
+	return h.checkPlayerToken(gameID, playerName, token, true)
+}
+
+// checkPlayerToken checks if the provided token matches the player's token,
+// accepting signed tokens of ended games when readOnly is set
+func (h *Handler) checkPlayerToken(gameID, playerName, token string, readOnly bool) error {
+	// Signed tokens carry the game and the player
+	if h.Tokens != nil {
+		claims, err := h.Tokens.Verify(token)
+		if err != nil {
+			return err
+		}
+		if claims.Role != auth.RolePlayer || claims.GameID != gameID || claims.Player != playerName {
+			return auth.ErrInvalidToken
+		}
+	}
+
 	// Get the game
 	game, err := h.GameManager.GetGame(gameID)
 	if err != nil {
@@ -36,14 +72,123 @@ func (h *Handler) validatePlayerToken(gameID, playerName, token string) error {
 		return errors.New("player not found")
 	}
 
-	// Check if the token matches
-	if playerInfo.Token != token {
+	if h.Tokens != nil {
+		// Signed tokens expire with the game, except to follow it
+		if game.Status == models.GameStatusEnd && !readOnly {
+			return auth.ErrExpiredToken
+		}
+		return nil
+	}
+
+	// Check if the token matches, in constant time so the comparison does
+	// not leak how much of the token is right
+	if subtle.ConstantTimeCompare([]byte(playerInfo.Token), []byte(token)) != 1 {
 		return errors.New("invalid token")
 	}
 
 	return nil
 }
 
+// issueToken returns the token handed to a player that joined a game: a
+// signed JWT when enabled, otherwise the token stored in the game
+func (h *Handler) issueToken(gameID, playerName, gameToken string) (string, *time.Time, error) {
+	if h.Tokens == nil {
+		return gameToken, nil, nil
+	}
+
+	token, expiresAt, err := h.Tokens.Sign(gameID, playerName)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, &expiresAt, nil
+}
+
+// boardsVisible reports whether anybody can see the boards of a game. Public
+// games show them, except while a game with delayed spectators is running:
+// the delay would mean nothing if the live boards were shown as well.
+func boardsVisible(gameObj *models.Game) bool {
+	if gameObj.Spectators == models.SpectatorDelayed && gameObj.Status != models.GameStatusEnd {
+		return false
+	}
+	return gameObj.Public
+}
+
+// limitedGame creates a copy of the game object with tokens hidden.
+// Boards are only included when includeBoards is set.
+func limitedGame(gameObj *models.Game, includeBoards bool) *models.Game {
+	limitedPlayers := make(map[string]models.PlayerInfo)
+	for name, player := range gameObj.Players {
+		playerInfo := models.PlayerInfo{
+			Ready:         player.Ready,
+			TotalCapacity: player.TotalCapacity,
+			Capacity:      player.Capacity,
+			Account:       player.Account,
+			Timeouts:      player.Timeouts,
+			Forfeited:     player.Forfeited,
+			Eliminated:    player.Eliminated,
+			Place:         player.Place,
+			Team:          player.Team,
+		}
+		if includeBoards {
+			playerInfo.Board = player.Board
+		}
+		limitedPlayers[name] = playerInfo
+	}
+
+	return &models.Game{
+		ID:             gameObj.ID,
+		Status:         gameObj.Status,
+		Turn:           gameObj.Turn,
+		Winner:         gameObj.Winner,
+		Public:         gameObj.Public,
+		Training:       gameObj.Training,
+		Elimination:    gameObj.Elimination,
+		Ranking:        gameObj.Ranking,
+		Teams:          gameObj.Teams,
+		WinnerTeam:     gameObj.WinnerTeam,
+		PartialDamage:  gameObj.PartialDamage,
+		Weather:        gameObj.Weather,
+		Round:          gameObj.Round,
+		Conditions:     gameObj.Conditions,
+		Forecast:       gameObj.Forecast,
+		Catalog:        gameObj.Catalog,
+		Spectators:     gameObj.Spectators,
+		SpectatorDelay: gameObj.SpectatorDelay,
+		TurnTimeout:    gameObj.TurnTimeout,
+		MaxTimeouts:    gameObj.MaxTimeouts,
+		TurnDeadline:   gameObj.TurnDeadline,
+		Players:        limitedPlayers,
+	}
+}
+
+// parseSeconds parses a whole number of seconds given either as a duration
+// ("60s", "2m") or as a plain number
+func parseSeconds(str string) (int, error) {
+	if seconds, err := strconv.Atoi(str); err == nil {
+		return seconds, nil
+	}
+
+	duration, err := time.ParseDuration(str)
+	if err != nil {
+		return 0, err
+	}
+	if duration%time.Second != 0 {
+		return 0, errors.New("duration should be a whole number of seconds")
+	}
+
+	return int(duration / time.Second), nil
+}
+
+// errorStatus returns the HTTP status for an error returned by the game manager.
+// Concurrent modifications are reported as 409 so clients know they can retry.
+func errorStatus(err error, status int) int {
+	if errors.Is(err, store.ErrConflict) {
+		return http.StatusConflict
+	}
+	return status
+}
+
 // @Summary Create a new game
 // @Description Creates a new game with the specified parameters
 // @Tags games
@@ -51,6 +196,18 @@ func (h *Handler) validatePlayerToken(gameID, playerName, token string) error {
 // @Produce json
 // @Param size query int false "Board size (5-20)" default(10)
 // @Param capacity query int false "Required capacity" default(1000)
+// @Param public query bool false "Public game, boards are visible to everyone (only once the game ends with delayed spectators)" default(false)
+// @Param training query bool false "Training game, enables coaching tools such as the opponent heatmap" default(false)
+// @Param elimination query bool false "Elimination game, knocked out players are out and the game goes on until a single player is left" default(false)
+// @Param catalog query string false "Plant catalog the game is played with, see /catalogs" default(classic)
+// @Param teams query bool false "Team game, four players play 2v2 until a team is knocked out" default(false)
+// @Param partial_damage query bool false "Each cell hit takes its share of the plant capacity, plants are destroyed when every cell is hit" default(false)
+// @Param weather query bool false "The weather of each round scales the output of WIND and SOLAR plants" default(false)
+// @Param weather_seed query int false "Seed of the weather, random by default"
+// @Param spectators query string false "What spectators see (blind, full, delayed)" default(blind)
+// @Param spectator_delay query int false "Moves the delayed spectator view is behind" default(3)
+// @Param turn_timeout query string false "Time limit of a turn, as a duration (60s, 2m) or in seconds; no limit by default"
+// @Param max_timeouts query int false "Expired turns in a row after which a player forfeits" default(3)
 // @Success 200 {object} models.Game
 // @Failure 400 {object} models.ErrorResponse
 // @Router /games [post]
@@ -59,11 +216,31 @@ func (h *Handler) CreateGame(c echo.Context) error {
 	sizeStr := c.QueryParam("size")
 	capacityStr := c.QueryParam("capacity")
 	publicStr := c.QueryParam("public")
+	trainingStr := c.QueryParam("training")
+	eliminationStr := c.QueryParam("elimination")
+	teamsStr := c.QueryParam("teams")
+	partialDamageStr := c.QueryParam("partial_damage")
+	weatherStr := c.QueryParam("weather")
+	weatherSeedStr := c.QueryParam("weather_seed")
+	catalog := c.QueryParam("catalog")
+	spectators := models.SpectatorMode(c.QueryParam("spectators"))
+	spectatorDelayStr := c.QueryParam("spectator_delay")
+	turnTimeoutStr := c.QueryParam("turn_timeout")
+	maxTimeoutsStr := c.QueryParam("max_timeouts")
 
 	// Default values
 	size := 10
 	capacity := 1000
 	public := false
+	training := false
+	elimination := false
+	teams := false
+	partialDamage := false
+	weather := false
+	var weatherSeed int64
+	spectatorDelay := 3
+	turnTimeout := 0
+	maxTimeouts := 0
 
 	// Parse size
 	if sizeStr != "" {
@@ -101,8 +278,131 @@ func (h *Handler) CreateGame(c echo.Context) error {
 		}
 	}
 
+	// Parse training
+	if trainingStr != "" {
+		var err error
+		training, err = strconv.ParseBool(trainingStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "INVALID_PARAMETERS",
+			})
+		}
+	}
+
+	// Parse elimination
+	if eliminationStr != "" {
+		var err error
+		elimination, err = strconv.ParseBool(eliminationStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "INVALID_PARAMETERS",
+			})
+		}
+	}
+
+	// Parse teams
+	if teamsStr != "" {
+		var err error
+		teams, err = strconv.ParseBool(teamsStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "INVALID_PARAMETERS",
+			})
+		}
+	}
+
+	// Parse partial damage
+	if partialDamageStr != "" {
+		var err error
+		partialDamage, err = strconv.ParseBool(partialDamageStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "INVALID_PARAMETERS",
+			})
+		}
+	}
+
+	// Parse weather
+	if weatherStr != "" {
+		var err error
+		weather, err = strconv.ParseBool(weatherStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "INVALID_PARAMETERS",
+			})
+		}
+	}
+
+	// Parse weather seed
+	if weatherSeedStr != "" {
+		var err error
+		weatherSeed, err = strconv.ParseInt(weatherSeedStr, 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "INVALID_PARAMETERS",
+			})
+		}
+	}
+
+	// Parse spectator delay
+	if spectatorDelayStr != "" {
+		var err error
+		spectatorDelay, err = strconv.Atoi(spectatorDelayStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "INVALID_PARAMETERS",
+			})
+		}
+	}
+
+	// Parse turn timeout
+	if turnTimeoutStr != "" {
+		seconds, err := parseSeconds(turnTimeoutStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "INVALID_PARAMETERS",
+			})
+		}
+		turnTimeout = seconds
+	}
+
+	// Parse max timeouts
+	if maxTimeoutsStr != "" {
+		var err error
+		maxTimeouts, err = strconv.Atoi(maxTimeoutsStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "INVALID_PARAMETERS",
+			})
+		}
+	}
+
 	// Create the game
-	gameObj, err := h.GameManager.CreateGame(size, capacity, public)
+	gameObj, err := h.GameManager.CreateGame(models.GameOptions{
+		Size:           size,
+		Capacity:       capacity,
+		Public:         public,
+		Training:       training,
+		Elimination:    elimination,
+		Teams:          teams,
+		PartialDamage:  partialDamage,
+		Weather:        weather,
+		WeatherSeed:    weatherSeed,
+		Catalog:        catalog,
+		Spectators:     spectators,
+		SpectatorDelay: spectatorDelay,
+		TurnTimeout:    turnTimeout,
+		MaxTimeouts:    maxTimeouts,
+	})
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Status: "ERROR",
@@ -113,9 +413,11 @@ func (h *Handler) CreateGame(c echo.Context) error {
 	return c.JSON(http.StatusOK, gameObj)
 }
 
-// JoinGameResponse represents the response for joining a game
+// JoinGameResponse represents the response for joining a game.
+// ExpiresAt is only set for signed tokens.
 type JoinGameResponse struct {
-	Token string `json:"token"`
+	Token     string     `json:"token"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
 
 // @Summary Join a game
@@ -125,8 +427,12 @@ type JoinGameResponse struct {
 // @Produce json
 // @Param id path string true "Game ID"
 // @Param player query string true "Player name"
+// @Param key query string false "API key of the player account, also accepted in the X-API-Key header"
+// @Param team query int false "Team to join in team games (1 or 2), the smallest team by default"
 // @Success 200 {object} JoinGameResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Router /games/{id}/join [post]
 func (h *Handler) JoinGame(c echo.Context) error {
 	// Get game ID from path
@@ -142,17 +448,242 @@ func (h *Handler) JoinGame(c echo.Context) error {
 		})
 	}
 
-	// Join the game
-	token, err := h.GameManager.JoinGame(id, playerName)
+	// Parse team
+	team := 0
+	if teamStr := c.QueryParam("team"); teamStr != "" {
+		var err error
+		team, err = strconv.Atoi(teamStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "INVALID_PARAMETERS",
+			})
+		}
+	}
+
+	// Join the game, with the player account if a key is given
+	var token string
+	var err error
+	if key := accountKey(c); key != "" {
+		token, err = h.GameManager.JoinAccount(id, playerName, key, team)
+	} else {
+		token, err = h.GameManager.JoinGame(id, playerName, team)
+	}
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		if err.Error() == "INVALID_KEY" {
+			return c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  err.Error(),
+			})
+		}
+		return c.JSON(errorStatus(err, http.StatusBadRequest), models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	token, expiresAt, err := h.issueToken(id, playerName, token)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Status: "ERROR",
 			Error:  err.Error(),
 		})
 	}
 
 	return c.JSON(http.StatusOK, JoinGameResponse{
-		Token: token,
+		Token:     token,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// @Summary Refresh a player token
+// @Description Issues a new signed token with a fresh expiry. Without signed tokens the current token is returned, as it does not expire.
+// @Tags players
+// @Accept json
+// @Produce json
+// @Param id path string true "Game ID"
+// @Param name path string true "Player name"
+// @Security BearerAuth
+// @Success 200 {object} JoinGameResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /games/{id}/players/{name}/token [post]
+func (h *Handler) RefreshToken(c echo.Context) error {
+	// Get the player authenticated by RequirePlayer
+	player := currentPlayer(c)
+
+	token, expiresAt, err := h.issueToken(player.GameID, player.Name, bearerToken(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, JoinGameResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// AddBotResponse represents the response for adding a bot to a game
+type AddBotResponse struct {
+	Name  string    `json:"name"`
+	Level bot.Level `json:"level"`
+}
+
+// @Summary Add a bot to a game
+// @Description Adds a computer opponent that joins the game, places a board, gets ready and plays its turns automatically until the game ends. Only the players of the game can add bots, with their token or the API key of their account.
+// @Tags games
+// @Accept json
+// @Produce json
+// @Param id path string true "Game ID"
+// @Param level query string false "Bot level (easy, medium, hard)" default(easy)
+// @Security BearerAuth
+// @Success 200 {object} AddBotResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /games/{id}/bots [post]
+func (h *Handler) AddBot(c echo.Context) error {
+	// Get game ID from path
+	id := c.Param("id")
+
+	gameObj, err := h.GameManager.GetGame(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	// Only the players of the game can add bots to it
+	if bearerToken(c) == "" && accountKey(c) == "" {
+		return c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  "MISSING_TOKEN",
+		})
+	}
+	if !h.isPlayer(c, gameObj) {
+		return c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  "INVALID_TOKEN",
+		})
+	}
+
+	// Get bot level from query
+	level := bot.Level(c.QueryParam("level"))
+	if level == "" {
+		level = bot.LevelEasy
+	}
+	if level != bot.LevelEasy && level != bot.LevelMedium && level != bot.LevelHard {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  "INVALID_PARAMETERS",
+		})
+	}
+
+	// Join the bot and let it play in the background
+	b, err := bot.Join(h.GameManager, id, level)
+	if err != nil {
+		return c.JSON(errorStatus(err, http.StatusBadRequest), models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+	ctx, cancel := h.GameManager.GameContext(context.Background(), id)
+	go func() {
+		defer cancel()
+		b.Play(ctx)
+	}()
+
+	return c.JSON(http.StatusOK, AddBotResponse{
+		Name:  b.Name,
+		Level: b.Level,
+	})
+}
+
+// @Summary List public games
+// @Description Lists the public games, oldest first and paginated with a cursor
+// @Tags games
+// @Accept json
+// @Produce json
+// @Param status query string false "Game status (PENDING, IN_PROGRESS, END)"
+// @Param size query int false "Board size"
+// @Param has_seat query bool false "Only games that can still be joined" default(false)
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Param limit query int false "Games per page (max 100)" default(20)
+// @Success 200 {object} models.LobbyResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /games [get]
+func (h *Handler) ListGames(c echo.Context) error {
+	filter := models.LobbyFilter{
+		Status: models.GameStatus(c.QueryParam("status")),
+		Cursor: c.QueryParam("cursor"),
+	}
+
+	// Parse status
+	switch filter.Status {
+	case "", models.GameStatusPending, models.GameStatusInProgress, models.GameStatusEnd:
+	default:
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  "INVALID_PARAMETERS",
+		})
+	}
+
+	// Parse size
+	if sizeStr := c.QueryParam("size"); sizeStr != "" {
+		var err error
+		filter.Size, err = strconv.Atoi(sizeStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "INVALID_PARAMETERS",
+			})
+		}
+	}
+
+	// Parse has_seat
+	if hasSeatStr := c.QueryParam("has_seat"); hasSeatStr != "" {
+		var err error
+		filter.HasSeat, err = strconv.ParseBool(hasSeatStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "INVALID_PARAMETERS",
+			})
+		}
+	}
+
+	// Parse limit
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		var err error
+		filter.Limit, err = strconv.Atoi(limitStr)
+		if err != nil || filter.Limit <= 0 {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "INVALID_PARAMETERS",
+			})
+		}
+	}
+
+	games, nextCursor, err := h.GameManager.ListGames(filter)
+	if errors.Is(err, store.ErrInvalidCursor) {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  "INVALID_PARAMETERS",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.LobbyResponse{
+		Games:      games,
+		NextCursor: nextCursor,
 	})
 }
 
@@ -178,34 +709,9 @@ func (h *Handler) GetGame(c echo.Context) error {
 		})
 	}
 
-	// Create a copy of the game object with tokens hidden
-	limitedGameObj := &models.Game{
-		ID:     gameObj.ID,
-		Status: gameObj.Status,
-		Turn:   gameObj.Turn,
-		Winner: gameObj.Winner,
-		Public: gameObj.Public,
-		Players: func() map[string]models.PlayerInfo {
-			limitedPlayers := make(map[string]models.PlayerInfo)
-			for name, player := range gameObj.Players {
-				playerInfo := models.PlayerInfo{
-					Ready:         player.Ready,
-					TotalCapacity: player.TotalCapacity,
-					Capacity:      player.Capacity,
-				}
-
-				// If the game is public, include the board
-				if gameObj.Public {
-					playerInfo.Board = player.Board
-				}
-
-				limitedPlayers[name] = playerInfo
-			}
-			return limitedPlayers
-		}(),
-	}
-
-	return c.JSON(http.StatusOK, limitedGameObj)
+	// Create a copy of the game object with tokens hidden.
+	// If the game is public, include the boards
+	return c.JSON(http.StatusOK, limitedGame(gameObj, boardsVisible(gameObj)))
 }
 
 // @Summary Set player ready
@@ -215,37 +721,23 @@ func (h *Handler) GetGame(c echo.Context) error {
 // @Produce json
 // @Param id path string true "Game ID"
 // @Param name path string true "Player name"
-// @Param token query string true "Player token"
+// @Param token query string false "Player token (deprecated, send it in the Authorization header)"
+// @Security BearerAuth
 // @Success 200 {object} models.ReadyResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
 // @Router /games/{id}/players/{name}/ready [post]
 func (h *Handler) SetPlayerReady(c echo.Context) error {
-	// Get game ID and player name from path
-	id := c.Param("id")
-	name := c.Param("name")
-
-	// Get token from query parameter
-	token := c.QueryParam("token")
-	if token == "" {
-		return c.JSON(http.StatusForbidden, models.ErrorResponse{
-			Status: "ERROR",
-			Error:  "MISSING_TOKEN",
-		})
-	}
-
-	// Validate the token
-	if err := h.validatePlayerToken(id, name, token); err != nil {
-		return c.JSON(http.StatusForbidden, models.ErrorResponse{
-			Status: "ERROR",
-			Error:  "INVALID_TOKEN",
-		})
-	}
+	// Get the player authenticated by RequirePlayer
+	player := currentPlayer(c)
+	id := player.GameID
+	name := player.Name
 
 	// Set the player as ready
 	err := h.GameManager.SetPlayerReady(id, name)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		return c.JSON(errorStatus(err, http.StatusBadRequest), models.ErrorResponse{
 			Status: "ERROR",
 			Error:  err.Error(),
 		})
@@ -263,35 +755,21 @@ func (h *Handler) SetPlayerReady(c echo.Context) error {
 // @Produce json
 // @Param id path string true "Game ID"
 // @Param name path string true "Player name"
-// @Param token query string true "Player token"
+// @Param token query string false "Player token (deprecated, send it in the Authorization header)"
+// @Security BearerAuth
 // @Param target query string true "Target player name"
 // @Param y query string true "Y coordinate (A-Z)"
 // @Param x query int true "X coordinate (1-size)"
 // @Success 200 {object} models.StrikeResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
 // @Router /games/{id}/players/{name}/strike [post]
 func (h *Handler) Strike(c echo.Context) error {
-	// Get game ID and player name from path
-	id := c.Param("id")
-	name := c.Param("name")
-
-	// Get token from query parameter
-	token := c.QueryParam("token")
-	if token == "" {
-		return c.JSON(http.StatusForbidden, models.ErrorResponse{
-			Status: "ERROR",
-			Error:  "MISSING_TOKEN",
-		})
-	}
-
-	// Validate the token
-	if err := h.validatePlayerToken(id, name, token); err != nil {
-		return c.JSON(http.StatusForbidden, models.ErrorResponse{
-			Status: "ERROR",
-			Error:  "INVALID_TOKEN",
-		})
-	}
+	// Get the player authenticated by RequirePlayer
+	player := currentPlayer(c)
+	id := player.GameID
+	name := player.Name
 
 	// Get query parameters
 	target := c.QueryParam("target")
@@ -321,7 +799,7 @@ func (h *Handler) Strike(c echo.Context) error {
 	// Perform the strike
 	result, err := h.GameManager.Strike(id, name, target, coord)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		return c.JSON(errorStatus(err, http.StatusBadRequest), models.ErrorResponse{
 			Status: "ERROR",
 			Error:  err.Error(),
 		})
@@ -340,33 +818,19 @@ func (h *Handler) Strike(c echo.Context) error {
 // @Produce json
 // @Param id path string true "Game ID"
 // @Param name path string true "Player name"
-// @Param token query string true "Player token"
+// @Param token query string false "Player token (deprecated, send it in the Authorization header)"
+// @Security BearerAuth
 // @Param board body models.Board true "Board configuration"
 // @Success 200 {object} models.Board
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Failure 403 {object} models.ErrorResponse
 // @Router /games/{id}/players/{name}/board [post]
 func (h *Handler) SetBoard(c echo.Context) error {
-	// Get game ID and player name from path
-	id := c.Param("id")
-	name := c.Param("name")
-
-	// Get token from query parameter
-	token := c.QueryParam("token")
-	if token == "" {
-		return c.JSON(http.StatusForbidden, models.ErrorResponse{
-			Status: "ERROR",
-			Error:  "MISSING_TOKEN",
-		})
-	}
-
-	// Validate the token
-	if err := h.validatePlayerToken(id, name, token); err != nil {
-		return c.JSON(http.StatusForbidden, models.ErrorResponse{
-			Status: "ERROR",
-			Error:  "INVALID_TOKEN",
-		})
-	}
+	// Get the player authenticated by RequirePlayer
+	player := currentPlayer(c)
+	id := player.GameID
+	name := player.Name
 
 	// Parse request body
 	board := new(models.Board)
@@ -380,7 +844,7 @@ func (h *Handler) SetBoard(c echo.Context) error {
 	// Set the board
 	updatedBoard, err := h.GameManager.SetBoard(id, name, board)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		return c.JSON(errorStatus(err, http.StatusBadRequest), models.ErrorResponse{
 			Status: "ERROR",
 			Error:  err.Error(),
 		})
@@ -389,30 +853,124 @@ func (h *Handler) SetBoard(c echo.Context) error {
 	return c.JSON(http.StatusOK, updatedBoard)
 }
 
+// Long polling limits for WaitForTurn
+const (
+	defaultWaitTimeout = 30 * time.Second
+	maxWaitTimeout     = 120 * time.Second
+)
+
+// parseWaitTimeout parses the timeout of a long poll, either a duration (30s)
+// or a number of seconds, returning def when it is empty
+func parseWaitTimeout(timeoutStr string, def time.Duration) (time.Duration, error) {
+	if timeoutStr == "" {
+		return def, nil
+	}
+
+	timeout, err := time.ParseDuration(timeoutStr)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(timeoutStr)
+		if convErr != nil {
+			return 0, convErr
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+	if timeout <= 0 || timeout > maxWaitTimeout {
+		return 0, errors.New("timeout out of range")
+	}
+
+	return timeout, nil
+}
+
+// @Summary Wait for the player's turn
+// @Description Long poll that blocks until it is the player's turn or the game has ended, or until the timeout expires. Returns the limited game view and the latest strike against the player.
+// @Tags players
+// @Accept json
+// @Produce json
+// @Param id path string true "Game ID"
+// @Param name path string true "Player name"
+// @Param token query string false "Player token (deprecated, send it in the Authorization header)"
+// @Security BearerAuth
+// @Param timeout query string false "Maximum time to wait (e.g. 30s, max 120s)" default(30s)
+// @Success 200 {object} models.WaitResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /games/{id}/players/{name}/wait [get]
+func (h *Handler) WaitForTurn(c echo.Context) error {
+	// Get the player authenticated by RequirePlayer
+	player := currentPlayer(c)
+	id := player.GameID
+	name := player.Name
+
+	// Parse timeout
+	timeout, err := parseWaitTimeout(c.QueryParam("timeout"), defaultWaitTimeout)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  "INVALID_PARAMETERS",
+		})
+	}
+
+	// Wait for the turn
+	ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+	defer cancel()
+
+	gameObj, err := h.GameManager.WaitForTurn(ctx, id, name)
+	if err != nil && gameObj == nil {
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	status := "YOUR_TURN"
+	if err != nil {
+		status = "TIMEOUT"
+	} else if gameObj.Status == models.GameStatusEnd {
+		status = "GAME_OVER"
+	}
+
+	return c.JSON(http.StatusOK, models.WaitResponse{
+		Status:     status,
+		Game:       limitedGame(gameObj, false),
+		LastStrike: game.LastStrikeAgainst(gameObj, name),
+	})
+}
+
 // @Summary Get player board
-// @Description Gets a player's board configuration
+// @Description Gets a player's board configuration, or the board of a teammate in team games
 // @Tags players
 // @Accept json
 // @Produce json
 // @Param id path string true "Game ID"
 // @Param name path string true "Player name"
-// @Param token query string true "Player token"
+// @Param teammate query string false "Teammate whose board to get"
+// @Param token query string false "Player token (deprecated, send it in the Authorization header)"
+// @Security BearerAuth
 // @Success 200 {object} models.Board
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Router /games/{id}/players/{name}/board [get]
 func (h *Handler) GetBoard(c echo.Context) error {
-	// Get game ID and player name from path
-	id := c.Param("id")
-	name := c.Param("name")
-
-	// Get token from query parameter
-	token := c.QueryParam("token")
-	// Validate the token
-	if err := h.validatePlayerToken(id, name, token); err != nil {
-		return c.JSON(http.StatusForbidden, models.ErrorResponse{
-			Status: "ERROR",
-			Error:  "INVALID_TOKEN",
-		})
+	// Get the player authenticated by RequirePlayer
+	player := currentPlayer(c)
+	id := player.GameID
+	name := player.Name
+
+	// Teammates can see each other's boards
+	if teammate := c.QueryParam("teammate"); teammate != "" && teammate != name {
+		board, err := h.GameManager.GetTeammateBoard(id, name, teammate)
+		if err != nil {
+			status := http.StatusNotFound
+			if err.Error() == "NOT_TEAMMATE" {
+				status = http.StatusForbidden
+			}
+			return c.JSON(status, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  err.Error(),
+			})
+		}
+		return c.JSON(http.StatusOK, board)
 	}
 
 	// Get the board
@@ -461,32 +1019,17 @@ func (h *Handler) GetOpponentBlindBoard(c echo.Context) error {
 // @Produce text/plain
 // @Param id path string true "Game ID"
 // @Param name path string true "Player name"
-// @Param token query string true "Player token"
+// @Param token query string false "Player token (deprecated, send it in the Authorization header)"
+// @Security BearerAuth
 // @Success 200 {string} string
 // @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Router /games/{id}/players/{name}/board/map [get]
 func (h *Handler) GetBoardMap(c echo.Context) error {
-	// Get game ID and player name from path
-	id := c.Param("id")
-	name := c.Param("name")
-
-	// Get token from query parameter
-	token := c.QueryParam("token")
-	if token == "" {
-		return c.JSON(http.StatusForbidden, models.ErrorResponse{
-			Status: "ERROR",
-			Error:  "MISSING_TOKEN",
-		})
-	}
-
-	// Validate the token
-	if err := h.validatePlayerToken(id, name, token); err != nil {
-		return c.JSON(http.StatusForbidden, models.ErrorResponse{
-			Status: "ERROR",
-			Error:  "INVALID_TOKEN",
-		})
-	}
+	// Get the player authenticated by RequirePlayer
+	player := currentPlayer(c)
+	id := player.GameID
+	name := player.Name
 
 	// Get the board map
 	boardMap, err := h.GameManager.GetBoardMap(id, name, false)
@@ -527,6 +1070,70 @@ func (h *Handler) GetOpponentBoardMap(c echo.Context) error {
 	return c.String(http.StatusOK, boardMap)
 }
 
+// @Summary Get opponent strike heatmap
+// @Description Gets, for each cell of an opponent's blind board, the number of possible plant placements covering it. Only available in training games.
+// @Tags players
+// @Accept json
+// @Produce json
+// @Param id path string true "Game ID"
+// @Param name path string true "Opponent name"
+// @Success 200 {object} models.Heatmap
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /games/{id}/opponent/{name}/heatmap [get]
+func (h *Handler) GetOpponentHeatmap(c echo.Context) error {
+	// Get game ID and opponent name from path
+	id := c.Param("id")
+	name := c.Param("name")
+
+	// Get the heatmap
+	heatmap, err := h.GameManager.GetOpponentHeatmap(id, name)
+	if err != nil {
+		return c.JSON(heatmapErrorStatus(err), models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, heatmap)
+}
+
+// @Summary Get opponent strike heatmap map
+// @Description Gets an ASCII representation of an opponent's strike heatmap, with densities scaled from 0 to 9. Only available in training games.
+// @Tags players
+// @Accept json
+// @Produce text/plain
+// @Param id path string true "Game ID"
+// @Param name path string true "Opponent name"
+// @Success 200 {string} string
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /games/{id}/opponent/{name}/heatmap/map [get]
+func (h *Handler) GetOpponentHeatmapMap(c echo.Context) error {
+	// Get game ID and opponent name from path
+	id := c.Param("id")
+	name := c.Param("name")
+
+	// Get the heatmap
+	heatmap, err := h.GameManager.GetOpponentHeatmap(id, name)
+	if err != nil {
+		return c.JSON(heatmapErrorStatus(err), models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	return c.String(http.StatusOK, heatmap.GenerateASCIIMap())
+}
+
+// heatmapErrorStatus returns the HTTP status for a heatmap error
+func heatmapErrorStatus(err error) int {
+	if err.Error() == "TRAINING_ONLY" {
+		return http.StatusForbidden
+	}
+	return http.StatusNotFound
+}
+
 // @Summary Get game status
 // @Description Gets the limited status of a game
 // @Tags games
@@ -550,24 +1157,88 @@ func (h *Handler) GetGameStatus(c echo.Context) error {
 	}
 
 	// Create a limited view of the game
-	limitedGameObj := &models.Game{
-		ID:     gameObj.ID,
-		Status: gameObj.Status,
-		Turn:   gameObj.Turn,
-		Winner: gameObj.Winner,
-		Public: gameObj.Public,
-		Players: func() map[string]models.PlayerInfo {
-			limitedPlayers := make(map[string]models.PlayerInfo)
-			for name, player := range gameObj.Players {
-				limitedPlayers[name] = models.PlayerInfo{
-					Ready:         player.Ready,
-					TotalCapacity: player.TotalCapacity,
-					Capacity:      player.Capacity,
-				}
-			}
-			return limitedPlayers
-		}(),
+	return c.JSON(http.StatusOK, limitedGame(gameObj, false))
+}
+
+// @Summary Get game replay
+// @Description Lists the moves of a finished game. With step, returns every board as it was after that move (0 is the starting position). Anybody can replay a public game; private games are only replayed for their players, with their token or the API key of their account.
+// @Tags games
+// @Accept json
+// @Produce json
+// @Param id path string true "Game ID"
+// @Param step query int false "Move number"
+// @Param key query string false "API key of a player account, also accepted in the X-API-Key header"
+// @Security BearerAuth
+// @Success 200 {array} models.ReplayMove
+// @Success 200 {object} models.ReplayStep
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /games/{id}/replay [get]
+func (h *Handler) GetReplay(c echo.Context) error {
+	// Get game ID from path
+	id := c.Param("id")
+
+	gameObj, err := h.GameManager.GetGame(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	// The players of a private game can replay it once it ended
+	player := !gameObj.Public && h.isReadingPlayer(c, gameObj)
+
+	// Without step, return the list of moves
+	stepStr := c.QueryParam("step")
+	if stepStr == "" {
+		moves, err := h.GameManager.GetReplay(id, player)
+		if err != nil {
+			return c.JSON(replayErrorStatus(err), models.ErrorResponse{
+				Status: "ERROR",
+				Error:  err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, moves)
+	}
+
+	// Parse step
+	step, err := strconv.Atoi(stepStr)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  "INVALID_PARAMETERS",
+		})
+	}
+
+	replayStep, err := h.GameManager.GetReplayStep(id, step, player)
+	if err != nil {
+		return c.JSON(replayErrorStatus(err), models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
 	}
 
-	return c.JSON(http.StatusOK, limitedGameObj)
+	return c.JSON(http.StatusOK, replayStep)
+}
+
+// replayErrorStatus returns the HTTP status for an error returned while
+// replaying a game. Anything unexpected is a failure of the replay itself
+// (e.g. an event that no longer applies), not of the request.
+func replayErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, game.ErrReplayNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, game.ErrGameNotFinished):
+		return http.StatusConflict
+	case errors.Is(err, game.ErrInvalidStep):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
 }
@@ -0,0 +1,419 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/xorduna/energywar/pkg/auth"
+	"github.com/xorduna/energywar/pkg/game"
+	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
+)
+
+// conflictStore fails every update as if another process had just modified
+// the game
+type conflictStore struct {
+	*store.MemoryStore
+}
+
+func (conflictStore) Update(id string, fn store.UpdateFunc) (*models.Game, error) {
+	return nil, store.ErrConflict
+}
+
+func TestJoinGameConflict(t *testing.T) {
+	gm := game.NewGameManager(conflictStore{store.NewMemoryStore()})
+	gameObj, err := gm.CreateGame(models.GameOptions{Size: 10, Capacity: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(gm)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/api/games/"+gameObj.ID+"/join?player=alice", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(gameObj.ID)
+
+	if err := h.JoinGame(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d", http.StatusConflict, rec.Code)
+	}
+
+	var response models.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Status != "ERROR" || response.Error != store.ErrConflict.Error() {
+		t.Fatalf("unexpected response %+v", response)
+	}
+}
+
+func TestDelayedGamesHideLiveBoards(t *testing.T) {
+	gameStore := store.NewMemoryStore()
+	gm := game.NewGameManager(gameStore)
+	gameObj, err := gm.CreateGame(models.GameOptions{
+		Size:           10,
+		Capacity:       1000,
+		Public:         true,
+		Spectators:     models.SpectatorDelayed,
+		SpectatorDelay: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, player := range []string{"alice", "bob"} {
+		if _, err := gm.JoinGame(gameObj.ID, player, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Both boards are set and the game is running
+	setStatus := func(status models.GameStatus) {
+		t.Helper()
+		_, err := gameStore.Update(gameObj.ID, func(game *models.Game) error {
+			game.Status = status
+			for name, info := range game.Players {
+				info.Board = &models.Board{Plants: []models.Plant{{Type: models.PlantTypeNuclear, Coordinates: []string{"A1"}}}}
+				game.Players[name] = info
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	getGame := func() *models.Game {
+		t.Helper()
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/games/"+gameObj.ID, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(gameObj.ID)
+		if err := NewHandler(gm).GetGame(c); err != nil {
+			t.Fatal(err)
+		}
+		var state models.Game
+		if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+			t.Fatal(err)
+		}
+		return &state
+	}
+
+	// Nobody reading the game sees the live boards
+	setStatus(models.GameStatusInProgress)
+	if board := getGame().Players["bob"].Board; board != nil {
+		t.Fatalf("expected the live board of bob to be hidden, got %+v", board)
+	}
+
+	// Everything is shown once the game is over
+	setStatus(models.GameStatusEnd)
+	if board := getGame().Players["bob"].Board; board == nil || len(board.Plants) != 1 {
+		t.Fatalf("expected the board of bob once the game ended, got %+v", board)
+	}
+}
+
+func TestDelayedSpectatorsSeeFullBoards(t *testing.T) {
+	gm := game.NewGameManager(store.NewMemoryStore())
+	gameObj, err := gm.CreateGame(models.GameOptions{
+		Size:           10,
+		Capacity:       1000,
+		Public:         true,
+		Spectators:     models.SpectatorDelayed,
+		SpectatorDelay: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nuclear := []string{"A1", "A2", "A3", "B1", "B2", "B3", "C1", "C2", "C3"}
+	for _, player := range []string{"alice", "bob"} {
+		if _, err := gm.JoinGame(gameObj.ID, player, 0); err != nil {
+			t.Fatal(err)
+		}
+		board := &models.Board{Plants: []models.Plant{{Type: models.PlantTypeNuclear, Coordinates: nuclear}}}
+		if _, err := gm.SetBoard(gameObj.ID, player, board); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, player := range []string{"alice", "bob"} {
+		if err := gm.SetPlayerReady(gameObj.ID, player); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Three misses, the spectators see the first one
+	for _, coord := range []string{"J1", "J2", "J3"} {
+		current, err := gm.GetGame(gameObj.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		target := "alice"
+		if current.Turn == "alice" {
+			target = "bob"
+		}
+		if _, err := gm.Strike(gameObj.ID, current.Turn, target, coord); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	current, err := gm.GetGame(gameObj.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	view, err := spectatorView(current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if view.Moves != 1 {
+		t.Fatalf("expected the view to be at move 1, got %d", view.Moves)
+	}
+	misses := 0
+	for name, info := range view.Game.Players {
+		if info.Board == nil || len(info.Board.Plants) != 1 || len(info.Board.Plants[0].Coordinates) != len(nuclear) {
+			t.Fatalf("expected the full board of %s, got %+v", name, info.Board)
+		}
+		misses += len(info.Board.Misses)
+	}
+	if misses != 1 {
+		t.Fatalf("expected the boards to show 1 miss, got %d", misses)
+	}
+}
+
+func TestAddBotNeedsPlayer(t *testing.T) {
+	gm := game.NewGameManager(store.NewMemoryStore())
+	gameObj, err := gm.CreateGame(models.GameOptions{Size: 10, Capacity: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := gm.JoinGame(gameObj.ID, "alice", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(gm)
+
+	addBot := func(token string) int {
+		t.Helper()
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/games/"+gameObj.ID+"/bots", nil)
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(gameObj.ID)
+		if err := h.AddBot(c); err != nil {
+			t.Fatal(err)
+		}
+		return rec.Code
+	}
+
+	if code := addBot(""); code != http.StatusForbidden {
+		t.Fatalf("expected status %d without a token, got %d", http.StatusForbidden, code)
+	}
+	if code := addBot("not-a-token"); code != http.StatusForbidden {
+		t.Fatalf("expected status %d with a bad token, got %d", http.StatusForbidden, code)
+	}
+	if code := addBot(token); code != http.StatusOK {
+		t.Fatalf("expected status %d with the token of a player, got %d", http.StatusOK, code)
+	}
+
+	// Deleting the game stops the bot
+	if err := gm.DeleteGame(gameObj.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplayFinishedGames(t *testing.T) {
+	gameStore := store.NewMemoryStore()
+	gm := game.NewGameManager(gameStore)
+	h := NewHandler(gm)
+
+	createGame := func(public bool) string {
+		t.Helper()
+		gameObj, err := gm.CreateGame(models.GameOptions{Size: 10, Capacity: 1000, Public: public})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, player := range []string{"alice", "bob"} {
+			if _, err := gm.JoinGame(gameObj.ID, player, 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return gameObj.ID
+	}
+	update := func(id string, fn store.UpdateFunc) {
+		t.Helper()
+		if _, err := gameStore.Update(id, fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	endGame := func(game *models.Game) error {
+		game.Status = models.GameStatusEnd
+		return nil
+	}
+	getReplay := func(id, query string) int {
+		t.Helper()
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/games/"+id+"/replay"+query, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		if err := h.GetReplay(c); err != nil {
+			t.Fatal(err)
+		}
+		return rec.Code
+	}
+
+	private := createGame(false)
+	update(private, endGame)
+	public := createGame(true)
+	update(public, endGame)
+	running := createGame(true)
+	broken := createGame(true)
+	update(broken, func(game *models.Game) error {
+		game.Status = models.GameStatusEnd
+		game.Events = nil
+		return nil
+	})
+
+	tests := []struct {
+		name  string
+		id    string
+		query string
+		want  int
+	}{
+		{"private moves without a token", private, "", http.StatusForbidden},
+		{"private step without a token", private, "?step=0", http.StatusForbidden},
+		{"private step with a bad token", private, "?step=0&token=not-a-token", http.StatusForbidden},
+		{"unknown game", "missing", "", http.StatusNotFound},
+		{"running game", running, "", http.StatusConflict},
+		{"bad step", public, "?step=x", http.StatusBadRequest},
+		{"step out of range", public, "?step=1", http.StatusBadRequest},
+		{"broken event log", broken, "?step=0", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := getReplay(tt.id, tt.query); code != tt.want {
+				t.Fatalf("expected status %d, got %d", tt.want, code)
+			}
+		})
+	}
+
+	// The public game can be replayed
+	if code := getReplay(public, "?step=0"); code != http.StatusOK {
+		t.Fatalf("expected status %d for the public game, got %d", http.StatusOK, code)
+	}
+}
+
+func TestPlayersReplayTheirPrivateGames(t *testing.T) {
+	gameStore := store.NewMemoryStore()
+	gm := game.NewGameManager(gameStore)
+	h := NewHandler(gm)
+	h.Tokens = auth.NewSigner([]byte(strings.Repeat("k", 32)), time.Hour)
+
+	key, err := gm.Register("carol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	createGame := func() string {
+		t.Helper()
+		gameObj, err := gm.CreateGame(models.GameOptions{Size: 10, Capacity: 1000})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return gameObj.ID
+	}
+	private, other := createGame(), createGame()
+	if _, err := gm.JoinGame(private, "alice", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gm.JoinAccount(private, "carol", key, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gm.JoinGame(other, "bob", 0); err != nil {
+		t.Fatal(err)
+	}
+	sign := func(gameID, player string) string {
+		t.Helper()
+		token, _, err := h.Tokens.Sign(gameID, player)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	alice, bob, spectator := sign(private, "alice"), sign(other, "bob"), sign(private, "")
+
+	// The tokens were handed out while the game was running
+	_, err = gameStore.Update(private, func(game *models.Game) error {
+		game.Status = models.GameStatusEnd
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   int
+	}{
+		{"player token", echo.HeaderAuthorization, "Bearer " + alice, http.StatusOK},
+		{"account key", "X-API-Key", key, http.StatusOK},
+		{"player of another game", echo.HeaderAuthorization, "Bearer " + bob, http.StatusForbidden},
+		{"spectator token", echo.HeaderAuthorization, "Bearer " + spectator, http.StatusForbidden},
+		{"wrong account key", "X-API-Key", "not-a-key", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, query := range []string{"", "?step=0"} {
+				e := echo.New()
+				req := httptest.NewRequest(http.MethodGet, "/api/games/"+private+"/replay"+query, nil)
+				req.Header.Set(tt.header, tt.value)
+				rec := httptest.NewRecorder()
+				c := e.NewContext(req, rec)
+				c.SetParamNames("id")
+				c.SetParamValues(private)
+				if err := h.GetReplay(c); err != nil {
+					t.Fatal(err)
+				}
+				if rec.Code != tt.want {
+					t.Fatalf("replay%s: expected status %d, got %d", query, tt.want, rec.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestJoinQueueBadTimeoutLeavesNoTicket(t *testing.T) {
+	gameStore := store.NewMemoryStore()
+	h := NewHandler(game.NewGameManager(gameStore))
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/api/matchmaking/queue?player=alice&timeout=garbage", nil)
+	rec := httptest.NewRecorder()
+	if err := h.JoinQueue(e.NewContext(req, rec)); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+
+	tickets, err := gameStore.ListTickets(store.TicketQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickets) != 0 {
+		t.Fatalf("expected no ticket to be queued, got %d", len(tickets))
+	}
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+// @Summary Join the matchmaking queue
+// @Description Queues a player for a game with the given parameters. Once enough players are waiting a game is created and every player joins it. The response carries a ticket to check the queue; with a timeout the request waits until the player is matched.
+// @Tags matchmaking
+// @Accept json
+// @Produce json
+// @Param player query string true "Player name"
+// @Param key query string false "API key of the player account, also accepted in the X-API-Key header"
+// @Param size query int false "Board size (5-20)" default(10)
+// @Param capacity query int false "Required capacity" default(1000)
+// @Param players query int false "Number of players (2-4)" default(2)
+// @Param timeout query string false "Wait until matched (e.g. 30s, max 120s)"
+// @Success 200 {object} models.QueueStatus
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /matchmaking/queue [post]
+func (h *Handler) JoinQueue(c echo.Context) error {
+	player := c.QueryParam("player")
+	if player == "" {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  "MISSING_PLAYER_NAME",
+		})
+	}
+
+	// Default values
+	opts := models.GameOptions{Size: 10, Capacity: 1000}
+	players := 2
+
+	// Parse parameters
+	params := []struct {
+		name  string
+		value *int
+	}{
+		{"size", &opts.Size},
+		{"capacity", &opts.Capacity},
+		{"players", &players},
+	}
+	for _, param := range params {
+		valueStr := c.QueryParam(param.name)
+		if valueStr == "" {
+			continue
+		}
+		value, err := strconv.Atoi(valueStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "INVALID_PARAMETERS",
+			})
+		}
+		*param.value = value
+	}
+
+	// Check the timeout before queueing, so a bad request leaves no ticket
+	// behind that could be matched without the player knowing
+	var timeout time.Duration
+	if timeoutStr := c.QueryParam("timeout"); timeoutStr != "" {
+		var err error
+		timeout, err = parseWaitTimeout(timeoutStr, defaultWaitTimeout)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "INVALID_PARAMETERS",
+			})
+		}
+	}
+
+	status, err := h.Matchmaking.Join(player, accountKey(c), opts, players)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "INVALID_KEY" {
+			status = http.StatusForbidden
+		}
+		return c.JSON(status, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	// Optionally wait for the match
+	if timeout > 0 && status.Status == models.QueueStatusWaiting {
+		return h.waitQueue(c, status.Ticket, timeout)
+	}
+
+	return h.queueResponse(c, status)
+}
+
+// @Summary Get matchmaking ticket status
+// @Description Returns the state of a queued player. With a timeout the request waits until the player is matched. Once matched it carries the game ID and the player token.
+// @Tags matchmaking
+// @Accept json
+// @Produce json
+// @Param ticket path string true "Ticket"
+// @Param timeout query string false "Wait until matched (e.g. 30s, max 120s)"
+// @Success 200 {object} models.QueueStatus
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /matchmaking/queue/{ticket} [get]
+func (h *Handler) GetQueueStatus(c echo.Context) error {
+	ticket := c.Param("ticket")
+
+	if timeoutStr := c.QueryParam("timeout"); timeoutStr != "" {
+		timeout, err := parseWaitTimeout(timeoutStr, defaultWaitTimeout)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "INVALID_PARAMETERS",
+			})
+		}
+		return h.waitQueue(c, ticket, timeout)
+	}
+
+	status, err := h.Matchmaking.Status(ticket)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	return h.queueResponse(c, status)
+}
+
+// waitQueue waits until the player of a ticket is matched or the timeout
+// expires, and responds with the ticket status
+func (h *Handler) waitQueue(c echo.Context, ticket string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+	defer cancel()
+
+	status, err := h.Matchmaking.Wait(ctx, ticket)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	return h.queueResponse(c, status)
+}
+
+// queueResponse responds with the status of a ticket, issuing the player
+// token the same way JoinGame does once the player is matched
+func (h *Handler) queueResponse(c echo.Context, status *models.QueueStatus) error {
+	if status.Status == models.QueueStatusMatched {
+		token, expiresAt, err := h.issueToken(status.GameID, status.Player, status.Token)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  err.Error(),
+			})
+		}
+		status.Token = token
+		status.ExpiresAt = expiresAt
+	}
+
+	return c.JSON(http.StatusOK, status)
+}
+
+// @Summary Leave the matchmaking queue
+// @Description Removes a player that is still waiting from the queue
+// @Tags matchmaking
+// @Accept json
+// @Produce json
+// @Param ticket path string true "Ticket"
+// @Success 200 {object} models.ReadyResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /matchmaking/queue/{ticket} [delete]
+func (h *Handler) LeaveQueue(c echo.Context) error {
+	if err := h.Matchmaking.Leave(c.Param("ticket")); err != nil {
+		status := http.StatusNotFound
+		if err.Error() == "ALREADY_MATCHED" {
+			status = http.StatusConflict
+		}
+		return c.JSON(status, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ReadyResponse{
+		Result: "OK",
+	})
+}
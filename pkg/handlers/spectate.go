@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/xorduna/energywar/pkg/auth"
+	"github.com/xorduna/energywar/pkg/game"
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+// validateSpectatorToken checks if the provided token lets its holder
+// spectate the game
+func (h *Handler) validateSpectatorToken(gameObj *models.Game, token string) error {
+	if h.Tokens != nil {
+		claims, err := h.Tokens.Verify(token)
+		if err != nil {
+			return err
+		}
+		if claims.Role != auth.RoleSpectator || claims.GameID != gameObj.ID {
+			return auth.ErrInvalidToken
+		}
+		return nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(gameObj.SpectatorToken), []byte(token)) != 1 {
+		return errors.New("invalid token")
+	}
+
+	return nil
+}
+
+// spectatingAllowed checks that the boards a game shows its spectators are
+// not more than anybody can already see. Full and delayed views are only
+// offered in public games.
+func spectatingAllowed(gameObj *models.Game) error {
+	if gameObj.Spectators != models.SpectatorBlind && !gameObj.Public {
+		return errors.New("SPECTATING_NOT_ALLOWED")
+	}
+	return nil
+}
+
+// isPlayer checks if a request comes from a player of the game, with their
+// token or the API key of their account
+func (h *Handler) isPlayer(c echo.Context, gameObj *models.Game) bool {
+	return h.checkPlayer(c, gameObj, false)
+}
+
+// isReadingPlayer checks if a request comes from a player of the game that
+// only reads it, accepting the signed tokens of ended games
+func (h *Handler) isReadingPlayer(c echo.Context, gameObj *models.Game) bool {
+	return h.checkPlayer(c, gameObj, true)
+}
+
+// checkPlayer checks if a request comes from a player of the game, with their
+// token or the API key of their account, accepting signed tokens of ended
+// games when readOnly is set
+func (h *Handler) checkPlayer(c echo.Context, gameObj *models.Game, readOnly bool) bool {
+	if token := bearerToken(c); token != "" {
+		for name := range gameObj.Players {
+			if h.checkPlayerToken(gameObj.ID, name, token, readOnly) == nil {
+				return true
+			}
+		}
+	}
+	if key := accountKey(c); key != "" {
+		for name, info := range gameObj.Players {
+			if info.Account && h.GameManager.Authenticate(name, key) == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// spectatorView assembles the game as its spectators see it: blind boards,
+// full boards, or the full boards as they were some moves ago
+func spectatorView(gameObj *models.Game) (*models.SpectatorView, error) {
+	view := &models.SpectatorView{
+		Mode:  gameObj.Spectators,
+		Delay: gameObj.SpectatorDelay,
+	}
+	for _, event := range gameObj.Events {
+		if event.Type == models.EventStrikeResolved {
+			view.Moves++
+		}
+	}
+
+	switch gameObj.Spectators {
+	case models.SpectatorFull:
+		view.Game = limitedGame(gameObj, true)
+	case models.SpectatorDelayed:
+		// Nothing is hidden anymore once the game is over
+		if gameObj.Status == models.GameStatusEnd {
+			view.Game = limitedGame(gameObj, true)
+			break
+		}
+		delayed, moves, err := game.Delayed(gameObj.Events, gameObj.SpectatorDelay)
+		if err != nil {
+			return nil, err
+		}
+		view.Game = limitedGame(delayed, true)
+		view.Moves = moves
+	default:
+		view.Game = limitedGame(gameObj, false)
+		for name, info := range view.Game.Players {
+			if board := gameObj.Players[name].Board; board != nil {
+				info.Board = board.GenerateBlindBoard()
+			}
+			view.Game.Players[name] = info
+		}
+	}
+
+	return view, nil
+}
+
+// @Summary Spectate a game
+// @Description Issues a read-only token to follow a game. Depending on the game settings spectators see blind boards, full boards, or full boards delayed by some moves. Full and delayed views are only offered in public games, whose boards are not secret: spectating is anonymous, so the players of the game can spectate it too.
+// @Tags spectators
+// @Accept json
+// @Produce json
+// @Param id path string true "Game ID"
+// @Success 200 {object} models.SpectateResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /games/{id}/spectate [post]
+func (h *Handler) Spectate(c echo.Context) error {
+	// Get game ID from path
+	id := c.Param("id")
+
+	gameObj, err := h.GameManager.GetGame(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	// Private games only show their boards to their players
+	if err := spectatingAllowed(gameObj); err != nil {
+		return c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	// Spectators get a signed token without a player when enabled
+	token, expiresAt, err := h.issueToken(id, "", gameObj.SpectatorToken)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.SpectateResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		Mode:      gameObj.Spectators,
+		Delay:     gameObj.SpectatorDelay,
+	})
+}
+
+// @Summary Get the spectator view
+// @Description Gets the game as spectators see it
+// @Tags spectators
+// @Accept json
+// @Produce json
+// @Param id path string true "Game ID"
+// @Param token query string false "Spectator token (deprecated, send it in the Authorization header)"
+// @Security BearerAuth
+// @Success 200 {object} models.SpectatorView
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /games/{id}/spectate [get]
+func (h *Handler) GetSpectatorView(c echo.Context) error {
+	// Get game ID from path
+	id := c.Param("id")
+
+	gameObj, err := h.GameManager.GetGame(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	token := bearerToken(c)
+	if token == "" {
+		return c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  "MISSING_TOKEN",
+		})
+	}
+	if err := h.validateSpectatorToken(gameObj, token); err != nil {
+		return c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  "INVALID_TOKEN",
+		})
+	}
+	if err := spectatingAllowed(gameObj); err != nil {
+		return c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	view, err := spectatorView(gameObj)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, view)
+}
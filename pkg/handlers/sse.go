@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/xorduna/energywar/pkg/game"
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+// sseKeepAlive is how often a comment is sent to keep idle streams open
+const sseKeepAlive = 15 * time.Second
+
+// @Summary Game notifications as Server-Sent Events
+// @Description Streams the changes of a game as Server-Sent Events: player_joined, player_ready, game_started, strike_result, turn_change and game_over. Each event id is the sequence number of the change, so a reconnecting client sending Last-Event-ID (or the last_event_id query parameter) receives every change it missed.
+// @Tags games
+// @Produce text/event-stream
+// @Param id path string true "Game ID"
+// @Param Last-Event-ID header int false "Last event ID received"
+// @Param last_event_id query int false "Last event ID received"
+// @Success 200 {object} models.GameMessage
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /games/{id}/events [get]
+func (h *Handler) GameEvents(c echo.Context) error {
+	// Get game ID from path
+	id := c.Param("id")
+
+	// Get the last event the client received
+	lastEventID := c.Request().Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.QueryParam("last_event_id")
+	}
+	lastSeq := 0
+	if lastEventID != "" {
+		var err error
+		lastSeq, err = strconv.Atoi(lastEventID)
+		if err != nil || lastSeq < 0 {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "INVALID_PARAMETERS",
+			})
+		}
+	}
+
+	// Subscribe before reading the log so no change is missed
+	sub := h.GameManager.Subscribe(id)
+	defer sub.Close()
+
+	events, err := h.GameManager.GetEvents(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	// Start the stream
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.WriteHeader(http.StatusOK)
+
+	// Send the changes the client missed
+	for _, event := range events {
+		if event.Seq <= lastSeq {
+			continue
+		}
+		if err := writeSSE(res, event); err != nil {
+			return nil
+		}
+		lastSeq = event.Seq
+	}
+	res.Flush()
+
+	// Stream new changes until the client goes away
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case event, ok := <-sub.Events:
+			if !ok {
+				return nil
+			}
+			// Skip changes already sent from the log
+			if event.Seq <= lastSeq {
+				continue
+			}
+			if err := writeSSE(res, event); err != nil {
+				return nil
+			}
+			lastSeq = event.Seq
+			res.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(res, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case <-c.Request().Context().Done():
+			return nil
+		}
+	}
+}
+
+// writeSSE writes the messages of a game event to an event stream
+func writeSSE(res *echo.Response, event models.Event) error {
+	for _, msg := range game.Messages(event) {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(res, "id: %d\nevent: %s\ndata: %s\n\n", msg.Seq, msg.Type, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
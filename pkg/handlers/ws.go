@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/xorduna/energywar/pkg/game"
+	"github.com/xorduna/energywar/pkg/models"
+	"golang.org/x/net/websocket"
+)
+
+// @Summary Game notifications over WebSocket
+// @Description Opens a WebSocket that pushes a message whenever the game changes: player_joined, player_ready, game_started, strike_result, turn_change and game_over. The first message is the current state. Players authenticate with their name and token to also receive their own board in the state. Spectators authenticate with their token only and receive a state message with the spectator view on every change. Without a token the connection is anonymous.
+// @Tags games
+// @Produce json
+// @Param id path string true "Game ID"
+// @Param player query string false "Player name"
+// @Param token query string false "Player or spectator token"
+// @Success 101 {object} models.GameMessage
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /games/{id}/ws [get]
+func (h *Handler) GameSocket(c echo.Context) error {
+	// Get game ID from path
+	id := c.Param("id")
+
+	// Make sure the game exists
+	gameObj, err := h.GameManager.GetGame(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Status: "ERROR",
+			Error:  err.Error(),
+		})
+	}
+
+	// Players authenticate with their name and token, spectators with their
+	// token only, anybody else connects anonymously
+	playerName := c.QueryParam("player")
+	token := bearerToken(c)
+	spectator := playerName == "" && token != ""
+	if spectator {
+		if err := h.validateSpectatorToken(gameObj, token); err != nil {
+			return c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "INVALID_TOKEN",
+			})
+		}
+		if err := spectatingAllowed(gameObj); err != nil {
+			return c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  err.Error(),
+			})
+		}
+	} else if playerName != "" {
+		// The socket is read-only, so players can still follow the game
+		// once it has ended
+		if err := h.validatePlayerReadToken(id, playerName, token); err != nil {
+			return c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Status: "ERROR",
+				Error:  "INVALID_TOKEN",
+			})
+		}
+	}
+
+	// Subscribe before reading the state so no change is missed
+	sub := h.GameManager.Subscribe(id)
+	defer sub.Close()
+
+	server := websocket.Server{
+		// Authentication is done with the token, so any origin is accepted
+		Handshake: func(*websocket.Config, *http.Request) error {
+			return nil
+		},
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			// Send the current state
+			seq, err := h.sendState(ws, id, playerName, spectator)
+			if err != nil {
+				return
+			}
+
+			// Detect when the client goes away
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				var msg string
+				for websocket.Message.Receive(ws, &msg) == nil {
+				}
+			}()
+
+			// Push every change until either side disconnects
+			for {
+				select {
+				case event, ok := <-sub.Events:
+					if !ok {
+						return
+					}
+					// Skip changes already in the state
+					if event.Seq <= seq {
+						continue
+					}
+					seq = event.Seq
+					// Spectators get the whole view again, as it may be
+					// behind the game
+					if spectator {
+						if seq, err = h.sendState(ws, id, playerName, spectator); err != nil {
+							return
+						}
+						continue
+					}
+					for _, msg := range game.Messages(event) {
+						if err := websocket.JSON.Send(ws, msg); err != nil {
+							return
+						}
+					}
+				case <-closed:
+					return
+				}
+			}
+		},
+	}
+	server.ServeHTTP(c.Response(), c.Request())
+
+	return nil
+}
+
+// sendState sends the current state of a game over a WebSocket: the
+// spectator view to spectators, and the limited game with their own board to
+// players. It returns the sequence number of the last change in the state.
+func (h *Handler) sendState(ws *websocket.Conn, gameID string, playerName string, spectator bool) (int, error) {
+	gameObj, err := h.GameManager.GetGame(gameID)
+	if err != nil {
+		return 0, err
+	}
+
+	var state *models.Game
+	if spectator {
+		view, err := spectatorView(gameObj)
+		if err != nil {
+			return 0, err
+		}
+		state = view.Game
+	} else {
+		state = limitedGame(gameObj, boardsVisible(gameObj))
+		if playerName != "" {
+			playerInfo := state.Players[playerName]
+			playerInfo.Board = gameObj.Players[playerName].Board
+			state.Players[playerName] = playerInfo
+		}
+	}
+
+	seq := len(gameObj.Events)
+
+	return seq, websocket.JSON.Send(ws, models.GameMessage{
+		Type: models.MessageState,
+		Seq:  seq,
+		Game: state,
+	})
+}
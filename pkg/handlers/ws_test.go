@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/xorduna/energywar/pkg/auth"
+	"github.com/xorduna/energywar/pkg/game"
+	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
+	"golang.org/x/net/websocket"
+)
+
+func TestGameSocketFollowsEndedGames(t *testing.T) {
+	gameStore := store.NewMemoryStore()
+	gm := game.NewGameManager(gameStore)
+	h := NewHandler(gm)
+	h.Tokens = auth.NewSigner([]byte(strings.Repeat("k", 32)), time.Hour)
+
+	gameObj, err := gm.CreateGame(models.GameOptions{Size: 10, Capacity: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gm.JoinGame(gameObj.ID, "alice", 0); err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := h.Tokens.Sign(gameObj.ID, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = gameStore.Update(gameObj.ID, func(game *models.Game) error {
+		game.Status = models.GameStatusEnd
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The token no longer works for actions
+	if err := h.validatePlayerToken(gameObj.ID, "alice", token); !errors.Is(err, auth.ErrExpiredToken) {
+		t.Fatalf("expected the token to have expired for actions, got %v", err)
+	}
+
+	// but still opens the socket
+	e := echo.New()
+	e.GET("/api/games/:id/ws", h.GameSocket)
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/games/" + gameObj.ID + "/ws?player=alice&token=" + token
+	ws, err := websocket.Dial(url, "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	var msg models.GameMessage
+	if err := websocket.JSON.Receive(ws, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != models.MessageState || msg.Game == nil || msg.Game.Status != models.GameStatusEnd {
+		t.Fatalf("expected the state of the ended game, got %+v", msg)
+	}
+}
@@ -0,0 +1,467 @@
+package matchmaking
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/xorduna/energywar/pkg/game"
+	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
+)
+
+// Ticket lifetimes
+const (
+	// abandonAfter is how long a waiting player can go without checking
+	// the queue before being dropped from it
+	abandonAfter = 30 * time.Second
+	// keepMatched is how long a matched ticket can still be looked up
+	keepMatched = 10 * time.Minute
+	// matchTimeout is how long a player can be held for a match before
+	// going back to the queue, in case the server placing them stopped
+	matchTimeout = time.Minute
+	// touchAfter is how often checking a ticket records that the player is
+	// still there, so polling players do not write on every request
+	touchAfter = 5 * time.Second
+)
+
+// pollInterval is how often Wait reads the ticket again, to notice the
+// matches made by other replicas
+const pollInterval = time.Second
+
+// updateRetries is how many times a ticket update is tried when it races
+// with another one
+const updateRetries = 5
+
+// errTaken is returned when claiming a ticket that is no longer waiting
+var errTaken = errors.New("ticket taken")
+
+// key groups the players that can play together
+type key struct {
+	Size     int
+	Capacity int
+	Players  int
+}
+
+// query selects the tickets of the key with the given status
+func (k key) query(status string) store.TicketQuery {
+	return store.TicketQuery{
+		Size:     k.Size,
+		Capacity: k.Capacity,
+		Players:  k.Players,
+		Status:   status,
+	}
+}
+
+// ticketKey returns the key of a ticket
+func ticketKey(t *models.QueueTicket) key {
+	return key{Size: t.Size, Capacity: t.Capacity, Players: t.Players}
+}
+
+// Queue pairs up waiting players and creates games for them.
+// Players are matched in the order they joined the queue. The tickets are
+// kept in the store, so players queued through different replicas sharing
+// it are matched together. A player is claimed for a match by moving its
+// ticket from WAITING to MATCHING, which only one replica can do.
+type Queue struct {
+	gm      *game.GameManager
+	tickets store.TicketStore
+
+	// matched is closed (and replaced) when this process makes a match,
+	// to wake up the players waiting on it
+	matched chan struct{}
+	mutex   sync.Mutex
+}
+
+// NewQueue creates a matchmaking queue kept in the store of the manager
+func NewQueue(gm *game.GameManager) *Queue {
+	return &Queue{
+		gm:      gm,
+		tickets: gm.Tickets(),
+		matched: make(chan struct{}),
+	}
+}
+
+// Join adds a player to the queue of games with the given parameters. When
+// enough players are waiting a game is created and all of them join it.
+// If a player cannot join, the game is deleted, that player is dropped from
+// the queue and the others wait for the next match.
+// Registered players pass their API key, guests an empty one.
+func (q *Queue) Join(player string, apiKey string, opts models.GameOptions, players int) (*models.QueueStatus, error) {
+	if player == "" {
+		return nil, errors.New("player name is required")
+	}
+	if players < 2 || players > game.MaxPlayers {
+		return nil, errors.New("players should be between 2 and 4")
+	}
+	if opts.Size < 5 || opts.Size > 20 {
+		return nil, errors.New("size should be between 5 and 20")
+	}
+	if opts.Capacity <= 0 {
+		return nil, errors.New("capacity should be greater than 0")
+	}
+
+	// Check the account now, the key is not kept in the queue
+	if apiKey != "" {
+		if err := q.gm.Authenticate(player, apiKey); err != nil {
+			return nil, err
+		}
+	} else if _, err := q.gm.GetAccount(player); err == nil {
+		return nil, errors.New("NAME_REGISTERED")
+	}
+
+	id, err := newTicketID()
+	if err != nil {
+		return nil, err
+	}
+
+	q.expire()
+
+	k := key{Size: opts.Size, Capacity: opts.Capacity, Players: players}
+
+	// Names must be unique within a game
+	waiting, err := q.tickets.ListTickets(k.query(models.QueueStatusWaiting))
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range waiting {
+		if t.Player == player {
+			return nil, errors.New("PLAYER_ALREADY_QUEUED")
+		}
+	}
+
+	now := time.Now()
+	t := &models.QueueTicket{
+		ID:        id,
+		Player:    player,
+		Account:   apiKey != "",
+		Size:      k.Size,
+		Capacity:  k.Capacity,
+		Players:   k.Players,
+		Status:    models.QueueStatusWaiting,
+		QueuedAt:  now,
+		LastSeen:  now,
+		UpdatedAt: now,
+	}
+	if err := q.tickets.CreateTicket(t); err != nil {
+		return nil, err
+	}
+
+	// Start games while enough players are waiting. The players claimed
+	// for a game are out of the queue, which keeps taking players meanwhile.
+	for {
+		group, err := q.take(k)
+		if err != nil {
+			return nil, err
+		}
+		if group == nil {
+			break
+		}
+
+		gameID, tokens, failed, err := q.match(group, opts)
+		q.finish(group, gameID, tokens, failed)
+		switch {
+		case err == nil:
+		case failed != nil && failed.ID == t.ID:
+			return nil, err
+		case failed == nil:
+			// The game could not be created, this player gets the error
+			// and the others keep waiting
+			q.Leave(t.ID)
+			return nil, err
+		}
+	}
+
+	return q.Status(t.ID)
+}
+
+// take claims the first group of players of the queue if enough of them are
+// waiting, or returns nil. When another replica claims one of them first,
+// the ones already claimed are put back and the queue is read again.
+func (q *Queue) take(k key) ([]*models.QueueTicket, error) {
+	for {
+		waiting, err := q.tickets.ListTickets(k.query(models.QueueStatusWaiting))
+		if err != nil {
+			return nil, err
+		}
+		if len(waiting) < k.Players {
+			return nil, nil
+		}
+
+		group := make([]*models.QueueTicket, 0, k.Players)
+		for _, t := range waiting[:k.Players] {
+			claimed, err := q.update(t.ID, func(t *models.QueueTicket) error {
+				if t.Status != models.QueueStatusWaiting {
+					return errTaken
+				}
+				t.Status = models.QueueStatusMatching
+				t.UpdatedAt = time.Now()
+				return nil
+			})
+			if errors.Is(err, errTaken) || errors.Is(err, store.ErrTicketNotFound) {
+				break
+			}
+			if err != nil {
+				q.requeue(group)
+				return nil, err
+			}
+			group = append(group, claimed)
+		}
+		if len(group) == k.Players {
+			return group, nil
+		}
+
+		q.requeue(group)
+	}
+}
+
+// match creates a game and joins the group of players to it. It returns the
+// game and the player tokens, or the ticket of the player that could not
+// join (nil if the game could not be created) with the error. A game that
+// could not be filled is deleted.
+func (q *Queue) match(group []*models.QueueTicket, opts models.GameOptions) (string, []string, *models.QueueTicket, error) {
+	gameObj, err := q.gm.CreateGame(models.GameOptions{Size: opts.Size, Capacity: opts.Capacity})
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	tokens := make([]string, len(group))
+	for i, t := range group {
+		if t.Account {
+			tokens[i], err = q.gm.JoinAuthenticated(gameObj.ID, t.Player, 0)
+		} else {
+			tokens[i], err = q.gm.JoinGame(gameObj.ID, t.Player, 0)
+		}
+		if err != nil {
+			if err := q.gm.DeleteGame(gameObj.ID); err != nil {
+				log.Printf("failed to delete unfilled game %s: %v", gameObj.ID, err)
+			}
+			return "", nil, t, err
+		}
+	}
+
+	return gameObj.ID, tokens, nil, nil
+}
+
+// finish places the group in the game it was matched to, or puts the players
+// back in their place in the queue when the match failed, without the player
+// that could not join
+func (q *Queue) finish(group []*models.QueueTicket, gameID string, tokens []string, failed *models.QueueTicket) {
+	defer q.signal()
+
+	if gameID == "" {
+		var requeued []*models.QueueTicket
+		for _, t := range group {
+			if failed != nil && t.ID == failed.ID {
+				if err := q.tickets.DeleteTicket(t.ID); err != nil {
+					log.Printf("failed to drop matchmaking ticket %s: %v", t.ID, err)
+				}
+				continue
+			}
+			requeued = append(requeued, t)
+		}
+		q.requeue(requeued)
+		return
+	}
+
+	now := time.Now()
+	for i, t := range group {
+		token := tokens[i]
+		_, err := q.update(t.ID, func(t *models.QueueTicket) error {
+			t.Status = models.QueueStatusMatched
+			t.GameID = gameID
+			t.Token = token
+			t.LastSeen = now
+			t.UpdatedAt = now
+			return nil
+		})
+		if err != nil {
+			log.Printf("failed to place matchmaking ticket %s in game %s: %v", t.ID, gameID, err)
+		}
+	}
+}
+
+// requeue puts claimed players back in the queue. They keep the time they
+// queued at, so they keep their place.
+func (q *Queue) requeue(group []*models.QueueTicket) {
+	for _, t := range group {
+		_, err := q.update(t.ID, func(t *models.QueueTicket) error {
+			t.Status = models.QueueStatusWaiting
+			t.UpdatedAt = time.Now()
+			return nil
+		})
+		if err != nil {
+			log.Printf("failed to requeue matchmaking ticket %s: %v", t.ID, err)
+		}
+	}
+}
+
+// update applies fn to a ticket, trying again when the update races with
+// another one. fn must check the ticket it gets, as it may have changed
+// between tries.
+func (q *Queue) update(ticketID string, fn store.UpdateTicketFunc) (*models.QueueTicket, error) {
+	var err error
+	for i := 0; i < updateRetries; i++ {
+		var t *models.QueueTicket
+		t, err = q.tickets.UpdateTicket(ticketID, fn)
+		if !errors.Is(err, store.ErrConflict) {
+			return t, err
+		}
+	}
+
+	return nil, err
+}
+
+// Status returns the state of a ticket
+func (q *Queue) Status(ticketID string) (*models.QueueStatus, error) {
+	t, err := q.tickets.GetTicket(ticketID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record that the player is still there
+	if time.Since(t.LastSeen) > touchAfter {
+		t, err = q.update(ticketID, func(t *models.QueueTicket) error {
+			t.LastSeen = time.Now()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return q.status(t)
+}
+
+// Wait blocks until the player of a ticket is placed in a game or ctx is
+// done, and returns the state of the ticket. Matches made by this process
+// wake it up right away, the ones made by other replicas within
+// pollInterval.
+func (q *Queue) Wait(ctx context.Context, ticketID string) (*models.QueueStatus, error) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Get the signal before reading the ticket so no match is missed
+		matched := q.changed()
+
+		status, err := q.Status(ticketID)
+		if err != nil || status.Status == models.QueueStatusMatched {
+			return status, err
+		}
+
+		select {
+		case <-matched:
+		case <-ticker.C:
+		case <-ctx.Done():
+			return status, nil
+		}
+	}
+}
+
+// Leave removes a waiting player from the queue
+func (q *Queue) Leave(ticketID string) error {
+	// Claim the ticket first, so no replica matches the player while it is
+	// being removed
+	_, err := q.update(ticketID, func(t *models.QueueTicket) error {
+		if t.Status != models.QueueStatusWaiting {
+			return errors.New("ALREADY_MATCHED")
+		}
+		t.Status = models.QueueStatusMatching
+		t.UpdatedAt = time.Now()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return q.tickets.DeleteTicket(ticketID)
+}
+
+// status describes a ticket
+func (q *Queue) status(t *models.QueueTicket) (*models.QueueStatus, error) {
+	status := &models.QueueStatus{
+		Ticket:  t.ID,
+		Player:  t.Player,
+		Size:    t.Size,
+		Players: t.Players,
+	}
+
+	if t.Status == models.QueueStatusMatched {
+		status.Status = models.QueueStatusMatched
+		status.GameID = t.GameID
+		status.Token = t.Token
+		return status, nil
+	}
+
+	waiting, err := q.tickets.ListTickets(ticketKey(t).query(models.QueueStatusWaiting))
+	if err != nil {
+		return nil, err
+	}
+	status.Status = models.QueueStatusWaiting
+	status.Waiting = len(waiting)
+	return status, nil
+}
+
+// expire drops the players that stopped checking the queue and the matched
+// tickets nobody looked up, and puts back in the queue the players held for a
+// match that never finished
+func (q *Queue) expire() {
+	tickets, err := q.tickets.ListTickets(store.TicketQuery{})
+	if err != nil {
+		log.Printf("failed to list matchmaking tickets: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, t := range tickets {
+		switch {
+		case t.Status == models.QueueStatusWaiting && now.Sub(t.LastSeen) > abandonAfter:
+			q.Leave(t.ID)
+		case t.Status == models.QueueStatusMatching && now.Sub(t.UpdatedAt) > matchTimeout:
+			q.update(t.ID, func(t *models.QueueTicket) error {
+				if t.Status != models.QueueStatusMatching || time.Since(t.UpdatedAt) <= matchTimeout {
+					return errTaken
+				}
+				t.Status = models.QueueStatusWaiting
+				t.UpdatedAt = time.Now()
+				return nil
+			})
+		case t.Status == models.QueueStatusMatched && now.Sub(t.LastSeen) > keepMatched:
+			q.tickets.DeleteTicket(t.ID)
+		}
+	}
+}
+
+// changed returns a channel that is closed the next time this process makes
+// a match
+func (q *Queue) changed() <-chan struct{} {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.matched
+}
+
+// signal wakes up the players waiting on this process
+func (q *Queue) signal() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	close(q.matched)
+	q.matched = make(chan struct{})
+}
+
+// newTicketID generates a random ticket ID. Tickets give access to the
+// player token once matched, so they must not be guessable.
+func newTicketID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
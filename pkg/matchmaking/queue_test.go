@@ -0,0 +1,233 @@
+package matchmaking
+
+import (
+	"crypto/rand"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/xorduna/energywar/pkg/game"
+	"github.com/xorduna/energywar/pkg/models"
+	"github.com/xorduna/energywar/pkg/store"
+)
+
+// testOptions are the options of the games the tests queue for
+var testOptions = models.GameOptions{Size: 10, Capacity: 1000}
+
+func TestFailedMatchRequeuesThePlayers(t *testing.T) {
+	gameStore := store.NewMemoryStore()
+	gm := game.NewGameManager(gameStore)
+	q := NewQueue(gm)
+
+	join := func(player string) *models.QueueStatus {
+		t.Helper()
+		status, err := q.Join(player, "", testOptions, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status
+	}
+
+	alice := join("alice")
+	bob := join("bob")
+
+	// bob registers the name while waiting, so the guest cannot join
+	if _, err := gm.Register("bob"); err != nil {
+		t.Fatal(err)
+	}
+	carol := join("carol")
+	if carol.Status != models.QueueStatusWaiting {
+		t.Fatalf("expected carol to keep waiting, got %s", carol.Status)
+	}
+
+	// The game alice had joined is gone
+	games, err := gameStore.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 0 {
+		t.Fatalf("expected the unfilled game to be deleted, got %d games", len(games))
+	}
+	if _, err := q.Status(bob.Ticket); err == nil {
+		t.Fatal("expected bob to be dropped from the queue")
+	}
+
+	// alice and carol are matched with the next player, in their order
+	dave := join("dave")
+	if dave.Status != models.QueueStatusMatched {
+		t.Fatalf("expected dave to be matched, got %s", dave.Status)
+	}
+	for _, ticket := range []string{alice.Ticket, carol.Ticket} {
+		status, err := q.Status(ticket)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Status != models.QueueStatusMatched || status.GameID != dave.GameID {
+			t.Fatalf("expected %s to be matched to %s, got %s %s", status.Player, dave.GameID, status.Status, status.GameID)
+		}
+	}
+
+	gameObj, err := gm.GetGame(dave.GameID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, player := range []string{"alice", "carol", "dave"} {
+		if _, joined := gameObj.Players[player]; !joined {
+			t.Fatalf("expected %s to be in the game", player)
+		}
+	}
+}
+
+func TestFailedGameCreationKeepsTheOthersWaiting(t *testing.T) {
+	gm := game.NewGameManager(store.NewMemoryStore())
+	q := NewQueue(gm)
+
+	alice, err := q.Join("alice", "", testOptions, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without random numbers the game cannot be created, bob gets the error
+	gm.SetRandom(strings.NewReader(""))
+	if _, err := q.Join("bob", "", testOptions, 2); err == nil {
+		t.Fatal("expected bob to get the error of the game creation")
+	}
+	gm.SetRandom(rand.Reader)
+
+	status, err := q.Status(alice.Ticket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != models.QueueStatusWaiting || status.Waiting != 1 {
+		t.Fatalf("expected alice to wait alone, got %s with %d waiting", status.Status, status.Waiting)
+	}
+
+	carol, err := q.Join("carol", "", testOptions, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if carol.Status != models.QueueStatusMatched {
+		t.Fatalf("expected carol to be matched with alice, got %s", carol.Status)
+	}
+}
+
+func TestConcurrentJoinsAreAllMatched(t *testing.T) {
+	const players = 100
+
+	gm := game.NewGameManager(store.NewMemoryStore())
+	q := NewQueue(gm)
+
+	tickets := make(chan string, players)
+	var wg sync.WaitGroup
+	for i := 0; i < players; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			status, err := q.Join(fmt.Sprintf("player%d", i), "", testOptions, 2)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			tickets <- status.Ticket
+		}(i)
+	}
+	wg.Wait()
+	close(tickets)
+
+	// Every game gets exactly two players
+	games := make(map[string]int)
+	for ticket := range tickets {
+		status, err := q.Status(ticket)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Status != models.QueueStatusMatched {
+			t.Fatalf("expected %s to be matched, got %s", status.Player, status.Status)
+		}
+		games[status.GameID]++
+	}
+	if len(games) != players/2 {
+		t.Fatalf("expected %d games, got %d", players/2, len(games))
+	}
+	for id, n := range games {
+		if n != 2 {
+			t.Fatalf("expected 2 players in game %s, got %d", id, n)
+		}
+	}
+}
+
+func TestReplicasShareTheQueue(t *testing.T) {
+	const players = 20
+
+	// Two stores on the same file act as two server replicas
+	path := filepath.Join(t.TempDir(), "energywar.db")
+	queues := make([]*Queue, 2)
+	for i := range queues {
+		s, err := store.NewSQLiteStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+		queues[i] = NewQueue(game.NewGameManager(s))
+	}
+
+	// alice queues on the first replica and bob on the second
+	alice, err := queues[0].Join("alice", "", testOptions, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := queues[1].Join("bob", "", testOptions, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bob.Status != models.QueueStatusMatched {
+		t.Fatalf("expected bob to be matched with alice, got %s", bob.Status)
+	}
+	status, err := queues[0].Status(alice.Ticket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != models.QueueStatusMatched || status.GameID != bob.GameID {
+		t.Fatalf("expected alice to be matched to %s, got %s %s", bob.GameID, status.Status, status.GameID)
+	}
+
+	// Players joining both replicas at once are each placed once
+	tickets := make(chan string, players)
+	var wg sync.WaitGroup
+	for i := 0; i < players; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			status, err := queues[i%2].Join(fmt.Sprintf("player%d", i), "", testOptions, 2)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			tickets <- status.Ticket
+		}(i)
+	}
+	wg.Wait()
+	close(tickets)
+
+	games := make(map[string]int)
+	for ticket := range tickets {
+		status, err := queues[1].Status(ticket)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Status != models.QueueStatusMatched {
+			t.Fatalf("expected %s to be matched, got %s", status.Player, status.Status)
+		}
+		games[status.GameID]++
+	}
+	if len(games) != players/2 {
+		t.Fatalf("expected %d games, got %d", players/2, len(games))
+	}
+	for id, n := range games {
+		if n != 2 {
+			t.Fatalf("expected 2 players in game %s, got %d", id, n)
+		}
+	}
+}
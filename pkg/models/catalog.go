@@ -0,0 +1,354 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxPlantSide is the largest width or height of a plant
+const maxPlantSide = 10
+
+// PlantSpec defines a plant type of a catalog. A plant covers a Width x
+// Height rectangle, or the cells listed in Cells for other shapes.
+type PlantSpec struct {
+	Type     PlantType `json:"type"`
+	Capacity int       `json:"capacity"`
+	Width    int       `json:"width"`
+	Height   int       `json:"height"`
+	// Cells are the {row, column} offsets of the cells of non-rectangular
+	// plants; Width and Height are then the size of their bounding box
+	Cells [][2]int `json:"cells,omitempty"`
+	// Mirror allows placing the plant mirrored as well as rotated
+	Mirror bool `json:"mirror,omitempty"`
+	// Count is the most plants of this type a board can have (0 for no limit)
+	Count int `json:"count,omitempty"`
+	// Symbol draws the plant on ASCII maps, the first letter of the type by
+	// default
+	Symbol string `json:"symbol,omitempty"`
+}
+
+// PlantShape is the footprint of a plant laid in one orientation: its cells
+// as {row, column} offsets from the top left corner of its bounding box
+type PlantShape struct {
+	// Rotation is clockwise, in degrees (0, 90, 180 or 270)
+	Rotation int
+	Mirrored bool
+	Cells    [][2]int
+}
+
+// Orientation tells whether the shape is laid as defined or turned a
+// quarter
+func (s PlantShape) Orientation() Orientation {
+	if s.Rotation%180 == 0 {
+		return OrientationHorizontal
+	}
+	return OrientationVertical
+}
+
+// Footprint returns the cells covered by the plant as defined, with the top
+// left corner of its bounding box at {0, 0}
+func (p PlantSpec) Footprint() [][2]int {
+	if len(p.Cells) > 0 {
+		return normalizeCells(p.Cells)
+	}
+
+	cells := make([][2]int, 0, p.Width*p.Height)
+	for y := 0; y < p.Height; y++ {
+		for x := 0; x < p.Width; x++ {
+			cells = append(cells, [2]int{y, x})
+		}
+	}
+	return cells
+}
+
+// Shapes returns every distinct shape the plant can be laid in: the four
+// rotations of its footprint, and their mirror images when allowed
+func (p PlantSpec) Shapes() []PlantShape {
+	var shapes []PlantShape
+	mirrors := []bool{false}
+	if p.Mirror {
+		mirrors = append(mirrors, true)
+	}
+
+	for _, mirrored := range mirrors {
+		cells := p.Footprint()
+		if mirrored {
+			cells = transformCells(cells, func(y, x int) (int, int) { return y, -x })
+		}
+		for rotation := 0; rotation < 360; rotation += 90 {
+			if !containsShape(shapes, cells) {
+				shapes = append(shapes, PlantShape{Rotation: rotation, Mirrored: mirrored, Cells: cells})
+			}
+			// Turn a quarter clockwise
+			cells = transformCells(cells, func(y, x int) (int, int) { return x, -y })
+		}
+	}
+
+	return shapes
+}
+
+// transformCells moves every cell with fn and normalizes the result
+func transformCells(cells [][2]int, fn func(y, x int) (int, int)) [][2]int {
+	moved := make([][2]int, len(cells))
+	for i, cell := range cells {
+		y, x := fn(cell[0], cell[1])
+		moved[i] = [2]int{y, x}
+	}
+	return normalizeCells(moved)
+}
+
+// normalizeCells moves cells so their bounding box starts at {0, 0} and
+// sorts them by row, then column
+func normalizeCells(cells [][2]int) [][2]int {
+	if len(cells) == 0 {
+		return nil
+	}
+
+	minY, minX := cells[0][0], cells[0][1]
+	for _, cell := range cells {
+		minY = min(minY, cell[0])
+		minX = min(minX, cell[1])
+	}
+
+	normalized := make([][2]int, len(cells))
+	for i, cell := range cells {
+		normalized[i] = [2]int{cell[0] - minY, cell[1] - minX}
+	}
+	sort.Slice(normalized, func(i, j int) bool {
+		if normalized[i][0] == normalized[j][0] {
+			return normalized[i][1] < normalized[j][1]
+		}
+		return normalized[i][0] < normalized[j][0]
+	})
+
+	return normalized
+}
+
+// MatchShape finds the shape of the plant covering exactly the given
+// {row, column} cells
+func (p PlantSpec) MatchShape(cells [][2]int) (PlantShape, bool) {
+	normalized := normalizeCells(cells)
+	for _, shape := range p.Shapes() {
+		if sameCells(shape.Cells, normalized) {
+			return shape, true
+		}
+	}
+	return PlantShape{}, false
+}
+
+// containsShape checks if a list of shapes already has the given cells
+func containsShape(shapes []PlantShape, cells [][2]int) bool {
+	for _, shape := range shapes {
+		if sameCells(shape.Cells, cells) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameCells compares two normalized lists of cells
+func sameCells(a [][2]int, b [][2]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// BoundingBox returns the width and height of the footprint of the plant
+func (p PlantSpec) BoundingBox() (int, int) {
+	width, height := 0, 0
+	for _, cell := range p.Footprint() {
+		width = max(width, cell[1]+1)
+		height = max(height, cell[0]+1)
+	}
+	return width, height
+}
+
+// distinct checks that no cell is listed twice
+func distinct(cells [][2]int) bool {
+	seen := make(map[[2]int]bool)
+	for _, cell := range cells {
+		if seen[cell] {
+			return false
+		}
+		seen[cell] = true
+	}
+	return true
+}
+
+// connected checks that every cell can be reached from the first one through
+// side-adjacent cells
+func connected(cells [][2]int) bool {
+	set := make(map[[2]int]bool)
+	for _, cell := range cells {
+		set[cell] = true
+	}
+
+	seen := map[[2]int]bool{cells[0]: true}
+	queue := [][2]int{cells[0]}
+	for len(queue) > 0 {
+		cell := queue[0]
+		queue = queue[1:]
+		for _, d := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+			next := [2]int{cell[0] + d[0], cell[1] + d[1]}
+			if set[next] && !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return len(seen) == len(set)
+}
+
+// PlantCatalog lists the plant types a game is played with
+type PlantCatalog struct {
+	Name   string      `json:"name"`
+	Plants []PlantSpec `json:"plants"`
+}
+
+// ClassicCatalog holds the original plants of the game
+var ClassicCatalog = &PlantCatalog{
+	Name: "classic",
+	Plants: []PlantSpec{
+		{Type: PlantTypeNuclear, Capacity: 1000, Width: 3, Height: 3},
+		{Type: PlantTypeGas, Capacity: 300, Width: 2, Height: 2},
+		{Type: PlantTypeWind, Capacity: 100, Width: 2, Height: 1},
+		{Type: PlantTypeSolar, Capacity: 25, Width: 1, Height: 1},
+	},
+}
+
+// LimitedCatalog holds the original plants with a limited number of the
+// biggest ones, so boards mix every plant type
+var LimitedCatalog = &PlantCatalog{
+	Name: "limited",
+	Plants: []PlantSpec{
+		{Type: PlantTypeNuclear, Capacity: 1000, Width: 3, Height: 3, Count: 1},
+		{Type: PlantTypeGas, Capacity: 300, Width: 2, Height: 2, Count: 2},
+		{Type: PlantTypeWind, Capacity: 100, Width: 2, Height: 1, Count: 4},
+		{Type: PlantTypeSolar, Capacity: 25, Width: 1, Height: 1},
+	},
+}
+
+// ShapesCatalog adds hydro dams shaped as an L and geothermal plants shaped
+// as a T to the original plants
+var ShapesCatalog = &PlantCatalog{
+	Name: "shapes",
+	Plants: []PlantSpec{
+		{Type: PlantTypeNuclear, Capacity: 1000, Width: 3, Height: 3, Count: 1},
+		{Type: PlantTypeGas, Capacity: 300, Width: 2, Height: 2, Count: 2},
+		{Type: "HYDRO", Capacity: 400, Width: 2, Height: 3, Cells: [][2]int{{0, 0}, {1, 0}, {2, 0}, {2, 1}}, Mirror: true, Count: 2, Symbol: "Y"},
+		{Type: "GEOTHERMAL", Capacity: 200, Width: 3, Height: 2, Cells: [][2]int{{0, 0}, {0, 1}, {0, 2}, {1, 1}}, Count: 2, Symbol: "T"},
+		{Type: PlantTypeWind, Capacity: 100, Width: 2, Height: 1, Count: 4},
+		{Type: PlantTypeSolar, Capacity: 25, Width: 1, Height: 1},
+	},
+}
+
+// DefaultCatalog is the catalog of games created without one
+var DefaultCatalog = ClassicCatalog
+
+// Catalogs lists the built-in catalogs
+var Catalogs = []*PlantCatalog{ClassicCatalog, LimitedCatalog, ShapesCatalog}
+
+// Plant returns the definition of a plant type
+func (c *PlantCatalog) Plant(plantType PlantType) (PlantSpec, bool) {
+	for _, plant := range c.Plants {
+		if plant.Type == plantType {
+			return plant, true
+		}
+	}
+	return PlantSpec{}, false
+}
+
+// Capacity returns the capacity of a plant type, 0 when it is not in the
+// catalog
+func (c *PlantCatalog) Capacity(plantType PlantType) int {
+	plant, _ := c.Plant(plantType)
+	return plant.Capacity
+}
+
+// Types lists the plant types of the catalog in catalog order
+func (c *PlantCatalog) Types() []PlantType {
+	types := make([]PlantType, 0, len(c.Plants))
+	for _, plant := range c.Plants {
+		types = append(types, plant.Type)
+	}
+	return types
+}
+
+// Symbol returns the letter drawing a plant type on ASCII maps
+func (c *PlantCatalog) Symbol(plantType PlantType) string {
+	if plant, exists := c.Plant(plantType); exists && plant.Symbol != "" {
+		return plant.Symbol
+	}
+	if plantType == "" {
+		return "?"
+	}
+	return string(plantType[0])
+}
+
+// Validate checks that the catalog defines at least one plant and that every
+// plant can be placed
+func (c *PlantCatalog) Validate() error {
+	if c.Name == "" {
+		return errors.New("catalog has no name")
+	}
+	if len(c.Plants) == 0 {
+		return fmt.Errorf("catalog %s has no plants", c.Name)
+	}
+
+	types := make(map[PlantType]bool)
+	for _, plant := range c.Plants {
+		if plant.Type == "" || strings.ToUpper(string(plant.Type)) != string(plant.Type) {
+			return fmt.Errorf("invalid plant type %q: types should be upper case", plant.Type)
+		}
+		if types[plant.Type] {
+			return fmt.Errorf("plant type %s is defined twice", plant.Type)
+		}
+		types[plant.Type] = true
+
+		if plant.Capacity <= 0 {
+			return fmt.Errorf("capacity of %s should be greater than 0", plant.Type)
+		}
+		if len(plant.Cells) > 0 {
+			if !distinct(plant.Cells) || !connected(plant.Cells) {
+				return fmt.Errorf("cells of %s should be distinct and side by side", plant.Type)
+			}
+			width, height := plant.BoundingBox()
+			if plant.Width != width || plant.Height != height {
+				return fmt.Errorf("size of %s should be the %dx%d bounding box of its cells", plant.Type, width, height)
+			}
+		}
+		if plant.Width < 1 || plant.Width > maxPlantSide || plant.Height < 1 || plant.Height > maxPlantSide {
+			return fmt.Errorf("size of %s should be between 1 and %d", plant.Type, maxPlantSide)
+		}
+		if plant.Count < 0 {
+			return fmt.Errorf("count of %s should not be negative", plant.Type)
+		}
+		// Hits, misses and empty cells have their own symbols
+		symbol := c.Symbol(plant.Type)
+		if len(symbol) != 1 {
+			return fmt.Errorf("symbol of %s should be a single character", plant.Type)
+		}
+		if strings.Contains("HM.", symbol) {
+			return fmt.Errorf("symbol %s of %s is reserved, set another symbol", symbol, plant.Type)
+		}
+	}
+
+	return nil
+}
+
+// PlantCatalog returns the catalog the game is played with
+func (g *Game) PlantCatalog() *PlantCatalog {
+	if g.Catalog != nil {
+		return g.Catalog
+	}
+	return DefaultCatalog
+}
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateCatalog(t *testing.T) {
+	nuclear := PlantSpec{Type: PlantTypeNuclear, Capacity: 1000, Width: 3, Height: 3}
+	hydro := PlantSpec{Type: "HYDRO", Capacity: 400, Width: 2, Height: 3, Cells: [][2]int{{0, 0}, {1, 0}, {2, 0}, {2, 1}}, Symbol: "Y"}
+	with := func(plant PlantSpec, fn func(plant *PlantSpec)) PlantSpec {
+		fn(&plant)
+		return plant
+	}
+
+	tests := []struct {
+		name    string
+		catalog PlantCatalog
+		err     string
+	}{
+		{name: "classic", catalog: *ClassicCatalog},
+		{name: "shapes", catalog: *ShapesCatalog},
+		{name: "no name", catalog: PlantCatalog{Plants: []PlantSpec{nuclear}}, err: "has no name"},
+		{name: "no plants", catalog: PlantCatalog{Name: "empty"}, err: "has no plants"},
+		{
+			name:    "duplicate types",
+			catalog: PlantCatalog{Name: "test", Plants: []PlantSpec{nuclear, with(nuclear, func(p *PlantSpec) { p.Width, p.Height = 1, 1 })}},
+			err:     "defined twice",
+		},
+		{
+			name:    "lower case type",
+			catalog: PlantCatalog{Name: "test", Plants: []PlantSpec{with(nuclear, func(p *PlantSpec) { p.Type = "nuclear" })}},
+			err:     "upper case",
+		},
+		{
+			name:    "zero capacity",
+			catalog: PlantCatalog{Name: "test", Plants: []PlantSpec{with(nuclear, func(p *PlantSpec) { p.Capacity = 0 })}},
+			err:     "capacity of NUCLEAR",
+		},
+		{
+			name:    "negative capacity",
+			catalog: PlantCatalog{Name: "test", Plants: []PlantSpec{with(nuclear, func(p *PlantSpec) { p.Capacity = -1 })}},
+			err:     "capacity of NUCLEAR",
+		},
+		{
+			name:    "zero width",
+			catalog: PlantCatalog{Name: "test", Plants: []PlantSpec{with(nuclear, func(p *PlantSpec) { p.Width = 0 })}},
+			err:     "size of NUCLEAR",
+		},
+		{
+			name:    "too high",
+			catalog: PlantCatalog{Name: "test", Plants: []PlantSpec{with(nuclear, func(p *PlantSpec) { p.Height = maxPlantSide + 1 })}},
+			err:     "size of NUCLEAR",
+		},
+		{name: "cells", catalog: PlantCatalog{Name: "test", Plants: []PlantSpec{hydro}}},
+		{
+			name:    "disconnected cells",
+			catalog: PlantCatalog{Name: "test", Plants: []PlantSpec{with(hydro, func(p *PlantSpec) { p.Cells = [][2]int{{0, 0}, {1, 0}, {2, 1}} })}},
+			err:     "distinct and side by side",
+		},
+		{
+			name:    "diagonal cells",
+			catalog: PlantCatalog{Name: "test", Plants: []PlantSpec{with(hydro, func(p *PlantSpec) { p.Width, p.Height, p.Cells = 2, 2, [][2]int{{0, 0}, {1, 1}} })}},
+			err:     "distinct and side by side",
+		},
+		{
+			name:    "repeated cells",
+			catalog: PlantCatalog{Name: "test", Plants: []PlantSpec{with(hydro, func(p *PlantSpec) { p.Cells = [][2]int{{0, 0}, {1, 0}, {1, 0}, {2, 0}, {2, 1}} })}},
+			err:     "distinct and side by side",
+		},
+		{
+			name:    "wrong bounding box",
+			catalog: PlantCatalog{Name: "test", Plants: []PlantSpec{with(hydro, func(p *PlantSpec) { p.Width, p.Height = 3, 2 })}},
+			err:     "2x3 bounding box",
+		},
+		{
+			name:    "negative count",
+			catalog: PlantCatalog{Name: "test", Plants: []PlantSpec{with(nuclear, func(p *PlantSpec) { p.Count = -1 })}},
+			err:     "count of NUCLEAR",
+		},
+		{
+			name:    "reserved symbol",
+			catalog: PlantCatalog{Name: "test", Plants: []PlantSpec{with(hydro, func(p *PlantSpec) { p.Symbol = "" })}},
+			err:     "symbol H of HYDRO is reserved",
+		},
+		{
+			name:    "long symbol",
+			catalog: PlantCatalog{Name: "test", Plants: []PlantSpec{with(hydro, func(p *PlantSpec) { p.Symbol = "HY" })}},
+			err:     "single character",
+		},
+	}
+	for _, test := range tests {
+		err := test.catalog.Validate()
+		if test.err == "" {
+			if err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Fatalf("%s: expected an error about %q, got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestMatchShape(t *testing.T) {
+	l := PlantSpec{Type: "HYDRO", Capacity: 400, Width: 2, Height: 3, Cells: [][2]int{{0, 0}, {1, 0}, {2, 0}, {2, 1}}}
+	mirrored := l
+	mirrored.Mirror = true
+
+	// Cells are given where they lie on a board, in any order
+	tests := []struct {
+		name     string
+		cells    [][2]int
+		rotation int
+		mirrored bool
+		plain    bool
+		mirror   bool
+	}{
+		{name: "L", cells: [][2]int{{5, 4}, {3, 3}, {4, 3}, {5, 3}}, rotation: 0, plain: true, mirror: true},
+		{name: "L turned a quarter", cells: [][2]int{{0, 0}, {0, 1}, {0, 2}, {1, 0}}, rotation: 90, plain: true, mirror: true},
+		{name: "L upside down", cells: [][2]int{{2, 7}, {2, 8}, {3, 8}, {4, 8}}, rotation: 180, plain: true, mirror: true},
+		{name: "L turned three quarters", cells: [][2]int{{0, 2}, {1, 0}, {1, 1}, {1, 2}}, rotation: 270, plain: true, mirror: true},
+		{name: "J", cells: [][2]int{{0, 1}, {1, 1}, {2, 0}, {2, 1}}, rotation: 0, mirrored: true, mirror: true},
+		{name: "J turned a quarter", cells: [][2]int{{0, 0}, {1, 0}, {1, 1}, {1, 2}}, rotation: 90, mirrored: true, mirror: true},
+		{name: "T", cells: [][2]int{{0, 0}, {0, 1}, {0, 2}, {1, 1}}},
+		{name: "S", cells: [][2]int{{0, 1}, {0, 2}, {1, 0}, {1, 1}}},
+		{name: "line", cells: [][2]int{{0, 0}, {1, 0}, {2, 0}, {3, 0}}},
+		{name: "square", cells: [][2]int{{0, 0}, {0, 1}, {1, 0}, {1, 1}}},
+		{name: "short L", cells: [][2]int{{0, 0}, {1, 0}, {1, 1}}},
+		{name: "long L", cells: [][2]int{{0, 0}, {1, 0}, {2, 0}, {3, 0}, {3, 1}}},
+	}
+	for _, test := range tests {
+		for _, plant := range []PlantSpec{l, mirrored} {
+			expected := test.plain
+			if plant.Mirror {
+				expected = test.mirror
+			}
+
+			shape, ok := plant.MatchShape(test.cells)
+			if ok != expected {
+				t.Fatalf("%s (mirror %v): expected match %v, got %v", test.name, plant.Mirror, expected, ok)
+			}
+			if ok && (shape.Rotation != test.rotation || shape.Mirrored != test.mirrored) {
+				t.Fatalf("%s (mirror %v): expected rotation %d mirrored %v, got %+v", test.name, plant.Mirror, test.rotation, test.mirrored, shape)
+			}
+		}
+	}
+}
+
+func TestShapesAreDistinct(t *testing.T) {
+	tests := []struct {
+		plant  PlantSpec
+		shapes int
+	}{
+		{plant: PlantSpec{Width: 3, Height: 3}, shapes: 1},
+		{plant: PlantSpec{Width: 2, Height: 1}, shapes: 2},
+		{plant: PlantSpec{Width: 2, Height: 1, Mirror: true}, shapes: 2},
+		{plant: PlantSpec{Width: 2, Height: 3, Cells: [][2]int{{0, 0}, {1, 0}, {2, 0}, {2, 1}}}, shapes: 4},
+		{plant: PlantSpec{Width: 2, Height: 3, Cells: [][2]int{{0, 0}, {1, 0}, {2, 0}, {2, 1}}, Mirror: true}, shapes: 8},
+		// A T is its own mirror image
+		{plant: PlantSpec{Width: 3, Height: 2, Cells: [][2]int{{0, 0}, {0, 1}, {0, 2}, {1, 1}}, Mirror: true}, shapes: 4},
+	}
+	for _, test := range tests {
+		if shapes := test.plant.Shapes(); len(shapes) != test.shapes {
+			t.Fatalf("%dx%d %v: expected %d shapes, got %d", test.plant.Width, test.plant.Height, test.plant.Cells, test.shapes, len(shapes))
+		}
+	}
+}
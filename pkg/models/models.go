@@ -3,7 +3,9 @@ package models
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 )
 
 // PlantType represents the type of power plant
@@ -25,17 +27,36 @@ const (
 	GameStatusEnd        GameStatus = "END"
 )
 
-// Plant represents a power plant on the board
+// Orientation represents how a plant is laid on the board
+type Orientation string
+
+const (
+	// OrientationHorizontal lays a plant as width x height, as defined in
+	// the catalog
+	OrientationHorizontal Orientation = "horizontal"
+	// OrientationVertical lays a plant rotated, as height x width
+	OrientationVertical Orientation = "vertical"
+)
+
+// Plant represents a power plant on the board. The orientation is worked
+// out from the coordinates when the board is set.
 type Plant struct {
-	Type        PlantType `json:"type"`
-	Coordinates []string  `json:"coordinates"`
+	Type        PlantType   `json:"type"`
+	Coordinates []string    `json:"coordinates"`
+	Orientation Orientation `json:"orientation,omitempty"`
+	// Rotation (clockwise, in degrees) and Mirrored tell how the footprint of
+	// plants with their own shape is laid
+	Rotation int  `json:"rotation,omitempty"`
+	Mirrored bool `json:"mirrored,omitempty"`
 }
 
 // Board represents a player's board
 type Board struct {
-	Plants        []Plant  `json:"plants"`
-	Hits          []string `json:"hits"`
-	Misses        []string `json:"misses"`
+	Plants []Plant  `json:"plants"`
+	Hits   []string `json:"hits"`
+	Misses []string `json:"misses"`
+	// Destroyed lists the cells of the plants with every cell hit
+	Destroyed     []string `json:"destroyed,omitempty"`
 	TotalCapacity int      `json:"total_capacity"`
 	Capacity      int      `json:"capacity"`
 }
@@ -47,6 +68,23 @@ type PlayerInfo struct {
 	Capacity      int    `json:"capacity"`
 	Token         string `json:"token,omitempty"`
 	Board         *Board `json:"board,omitempty"`
+	// Account is set when the player joined with a registered account
+	Account bool `json:"account"`
+	// Timeouts counts the turns in a row the player let expire; after too
+	// many the player forfeits and is out of the game
+	Timeouts  int  `json:"timeouts,omitempty"`
+	Forfeited bool `json:"forfeited,omitempty"`
+	// Eliminated is set when the player is knocked out of an elimination
+	// game. Place is the final place of a player that is out of the game.
+	Eliminated bool `json:"eliminated,omitempty"`
+	Place      int  `json:"place,omitempty"`
+	// Team is the team of the player in team games (1 or 2)
+	Team int `json:"team,omitempty"`
+}
+
+// InGame reports whether the player still takes turns and can be struck
+func (p PlayerInfo) InGame() bool {
+	return !p.Forfeited && !p.Eliminated
 }
 
 // Game represents a game session
@@ -59,38 +97,162 @@ type Game struct {
 	Size     int                   `json:"-"`
 	Capacity int                   `json:"-"`
 	Public   bool                  `json:"visibility"`
+	Training bool                  `json:"training"`
+	// Elimination games go on until a single player is left, knocked out
+	// players are out of the game. Ranking is the final order of the
+	// players, winner first.
+	Elimination bool     `json:"elimination,omitempty"`
+	Ranking     []string `json:"ranking,omitempty"`
+	// Teams games are played 2v2, WinnerTeam is the team that won
+	Teams      bool `json:"teams,omitempty"`
+	WinnerTeam int  `json:"winner_team,omitempty"`
+	// PartialDamage games take a share of the capacity of a plant for each
+	// cell hit, instead of the whole plant at the first hit
+	PartialDamage bool `json:"partial_damage,omitempty"`
+	// Weather games scale the output of WIND and SOLAR plants with the
+	// weather of each round, drawn from WeatherSeed. A round ends once every
+	// player still in the game has had a turn. Forecast is the weather of
+	// the next round.
+	Weather     bool               `json:"weather,omitempty"`
+	WeatherSeed int64              `json:"-"`
+	Round       int                `json:"round,omitempty"`
+	RoundTurns  int                `json:"-"`
+	Conditions  *WeatherConditions `json:"conditions,omitempty"`
+	Forecast    *WeatherConditions `json:"forecast,omitempty"`
+	// Catalog defines the plants of the game, the default catalog when nil.
+	// Use PlantCatalog to read it.
+	Catalog *PlantCatalog `json:"catalog,omitempty"`
+	// Spectators sets what spectators see, SpectatorDelay is the number of
+	// moves the delayed view is behind
+	Spectators     SpectatorMode `json:"spectators"`
+	SpectatorDelay int           `json:"spectator_delay,omitempty"`
+	SpectatorToken string        `json:"-"`
+	// TurnTimeout is the time limit of a turn in seconds (0 for none).
+	// TurnDeadline is when the current turn expires.
+	TurnTimeout  int        `json:"turn_timeout,omitempty"`
+	MaxTimeouts  int        `json:"max_timeouts,omitempty"`
+	TurnDeadline *time.Time `json:"turn_deadline,omitempty"`
+	Version      int64      `json:"-"`
+	Events       []Event    `json:"-"`
+}
+
+// WeatherConditions represents the weather of a round, as the share of their
+// capacity (in percent) that SOLAR and WIND plants produce
+type WeatherConditions struct {
+	Round int `json:"round"`
+	Sun   int `json:"sun"`
+	Wind  int `json:"wind"`
 }
 
-// PlantCapacity returns the capacity of a plant type
-func PlantCapacity(plantType PlantType) int {
-	switch plantType {
-	case PlantTypeNuclear:
-		return 1000
-	case PlantTypeGas:
-		return 300
-	case PlantTypeWind:
+// Output returns the share of its capacity (in percent) a plant type produces
+// in these conditions
+func (w *WeatherConditions) Output(plantType PlantType) int {
+	switch {
+	case w == nil:
 		return 100
-	case PlantTypeSolar:
-		return 25
+	case plantType == PlantTypeSolar:
+		return w.Sun
+	case plantType == PlantTypeWind:
+		return w.Wind
 	default:
-		return 0
+		return 100
 	}
 }
 
-// PlantSize returns the size of a plant type as [width, height]
-func PlantSize(plantType PlantType) [2]int {
-	switch plantType {
-	case PlantTypeNuclear:
-		return [2]int{3, 3}
-	case PlantTypeGas:
-		return [2]int{2, 2}
-	case PlantTypeWind:
-		return [2]int{2, 1}
-	case PlantTypeSolar:
-		return [2]int{1, 1}
-	default:
-		return [2]int{0, 0}
-	}
+// SpectatorMode represents what the spectators of a game can see
+type SpectatorMode string
+
+const (
+	// SpectatorBlind shows only hits and misses
+	SpectatorBlind SpectatorMode = "blind"
+	// SpectatorFull shows every board as it is
+	SpectatorFull SpectatorMode = "full"
+	// SpectatorDelayed shows every board as it was a few moves ago
+	SpectatorDelayed SpectatorMode = "delayed"
+)
+
+// GameOptions represents the parameters a game is created with
+type GameOptions struct {
+	Size           int           `json:"size"`
+	Capacity       int           `json:"capacity"`
+	Public         bool          `json:"public"`
+	Training       bool          `json:"training"`
+	Elimination    bool          `json:"elimination,omitempty"`
+	Teams          bool          `json:"teams,omitempty"`
+	PartialDamage  bool          `json:"partial_damage,omitempty"`
+	Weather        bool          `json:"weather,omitempty"`
+	WeatherSeed    int64         `json:"weather_seed,omitempty"`
+	Catalog        string        `json:"catalog,omitempty"`
+	Spectators     SpectatorMode `json:"spectators,omitempty"`
+	SpectatorDelay int           `json:"spectator_delay,omitempty"`
+	TurnTimeout    int           `json:"turn_timeout,omitempty"`
+	MaxTimeouts    int           `json:"max_timeouts,omitempty"`
+}
+
+// EventType represents the type of a game event
+type EventType string
+
+const (
+	EventGameCreated    EventType = "GAME_CREATED"
+	EventPlayerJoined   EventType = "PLAYER_JOINED"
+	EventBoardSet       EventType = "BOARD_SET"
+	EventPlayerReady    EventType = "PLAYER_READY"
+	EventStrikeResolved EventType = "STRIKE_RESOLVED"
+	EventGameEnded      EventType = "GAME_ENDED"
+	EventTurnSkipped    EventType = "TURN_SKIPPED"
+	EventPlayerForfeit  EventType = "PLAYER_FORFEITED"
+)
+
+// Event represents a single action recorded in a game's event log.
+// Only the fields relevant to the event type are set, except Status and Turn
+// which always hold the state of the game right after the event.
+type Event struct {
+	Seq     int           `json:"seq"`
+	Type    EventType     `json:"type"`
+	Time    time.Time     `json:"time"`
+	GameID  string        `json:"game_id"`
+	Options *GameOptions  `json:"options,omitempty"`
+	Catalog *PlantCatalog `json:"catalog,omitempty"`
+	Player  string        `json:"player,omitempty"`
+	Token   string        `json:"token,omitempty"`
+	Account bool          `json:"account,omitempty"`
+	Team    int           `json:"team,omitempty"`
+	Board   *Board        `json:"board,omitempty"`
+	Target  string        `json:"target,omitempty"`
+	Coord   string        `json:"coord,omitempty"`
+	Result  string        `json:"result,omitempty"`
+	Winner  *string       `json:"winner,omitempty"`
+	Status  GameStatus    `json:"status"`
+	Turn    string        `json:"turn,omitempty"`
+}
+
+// MessageType represents the type of a game notification pushed to clients
+type MessageType string
+
+const (
+	MessageState        MessageType = "state"
+	MessagePlayerJoined MessageType = "player_joined"
+	MessagePlayerReady  MessageType = "player_ready"
+	MessageGameStarted  MessageType = "game_started"
+	MessageStrikeResult MessageType = "strike_result"
+	MessageTurnChange   MessageType = "turn_change"
+	MessageGameOver     MessageType = "game_over"
+	MessageTurnSkipped  MessageType = "turn_skipped"
+	MessageForfeit      MessageType = "player_forfeited"
+)
+
+// GameMessage represents a notification about a change in a game
+type GameMessage struct {
+	Type       MessageType `json:"type"`
+	Seq        int         `json:"seq"`
+	Player     string      `json:"player,omitempty"`
+	Target     string      `json:"target,omitempty"`
+	Coordinate string      `json:"coordinate,omitempty"`
+	Result     string      `json:"result,omitempty"`
+	Turn       string      `json:"turn,omitempty"`
+	Team       int         `json:"team,omitempty"`
+	Winner     *string     `json:"winner,omitempty"`
+	Game       *Game       `json:"game,omitempty"`
 }
 
 // ValidateCoordinate checks if a coordinate is valid for the given board size
@@ -144,13 +306,84 @@ func (b *Board) GenerateBlindBoard() *Board {
 	return &Board{
 		Hits:          b.Hits,
 		Misses:        b.Misses,
+		Destroyed:     b.Destroyed,
 		TotalCapacity: b.TotalCapacity,
 		Capacity:      b.Capacity,
 	}
 }
 
-// GenerateASCIIMap generates an ASCII representation of the board
-func (b *Board) GenerateASCIIMap(size int, blind bool) string {
+// OpenHits lists the hits on plants that are not destroyed yet
+func (b *Board) OpenHits() []string {
+	var hits []string
+	for _, hit := range b.Hits {
+		if !slices.Contains(b.Destroyed, hit) {
+			hits = append(hits, hit)
+		}
+	}
+	return hits
+}
+
+// Clone returns a deep copy of the board
+func (b *Board) Clone() *Board {
+	if b == nil {
+		return nil
+	}
+
+	clone := &Board{
+		Hits:          append([]string(nil), b.Hits...),
+		Misses:        append([]string(nil), b.Misses...),
+		Destroyed:     append([]string(nil), b.Destroyed...),
+		TotalCapacity: b.TotalCapacity,
+		Capacity:      b.Capacity,
+	}
+	if b.Plants != nil {
+		clone.Plants = make([]Plant, len(b.Plants))
+		for i, plant := range b.Plants {
+			clone.Plants[i] = Plant{
+				Type:        plant.Type,
+				Coordinates: append([]string(nil), plant.Coordinates...),
+				Orientation: plant.Orientation,
+				Rotation:    plant.Rotation,
+				Mirrored:    plant.Mirrored,
+			}
+		}
+	}
+
+	return clone
+}
+
+// Clone returns a deep copy of the game, so callers can read or modify it
+// without affecting the stored state
+func (g *Game) Clone() *Game {
+	if g == nil {
+		return nil
+	}
+
+	clone := *g
+	if g.Winner != nil {
+		winner := *g.Winner
+		clone.Winner = &winner
+	}
+	if g.TurnDeadline != nil {
+		deadline := *g.TurnDeadline
+		clone.TurnDeadline = &deadline
+	}
+	clone.Ranking = append([]string(nil), g.Ranking...)
+	clone.Players = make(map[string]PlayerInfo, len(g.Players))
+	for name, info := range g.Players {
+		info.Board = info.Board.Clone()
+		clone.Players[name] = info
+	}
+	// The catalog and events are never modified once recorded, so they can
+	// be shared
+	clone.Events = append([]Event(nil), g.Events...)
+
+	return &clone
+}
+
+// GenerateASCIIMap generates an ASCII representation of the board, drawing
+// the plants with the symbols of the catalog
+func (b *Board) GenerateASCIIMap(size int, blind bool, catalog *PlantCatalog) string {
 	// Create a 2D grid
 	grid := make([][]string, size)
 	for i := range grid {
@@ -169,7 +402,7 @@ func (b *Board) GenerateASCIIMap(size int, blind bool) string {
 					continue
 				}
 				if y >= 0 && y < size && x >= 0 && x < size {
-					grid[y][x] = string(plant.Type[0])
+					grid[y][x] = catalog.Symbol(plant.Type)
 				}
 			}
 		}
@@ -219,18 +452,233 @@ func (b *Board) GenerateASCIIMap(size int, blind bool) string {
 	return sb.String()
 }
 
+// Heatmap represents, for each cell of a blind board, the number of possible
+// plant placements covering it
+type Heatmap struct {
+	Size   int      `json:"size"`
+	Cells  [][]int  `json:"cells"`
+	Max    int      `json:"max"`
+	Hits   []string `json:"hits"`
+	Misses []string `json:"misses"`
+}
+
+// GenerateASCIIMap generates an ASCII representation of the heatmap.
+// Each cell shows its density scaled from 0 to 9, or H/M if already struck.
+func (h *Heatmap) GenerateASCIIMap() string {
+	grid := make([][]string, h.Size)
+	for y := range grid {
+		grid[y] = make([]string, h.Size)
+		for x := range grid[y] {
+			level := 0
+			if h.Max > 0 {
+				level = h.Cells[y][x] * 9 / h.Max
+			}
+			grid[y][x] = fmt.Sprintf("%d", level)
+		}
+	}
+
+	// Place hits and misses
+	for marker, coords := range map[string][]string{"H": h.Hits, "M": h.Misses} {
+		for _, coord := range coords {
+			y, x, err := ParseCoordinate(coord)
+			if err != nil {
+				continue
+			}
+			if y >= 0 && y < h.Size && x >= 0 && x < h.Size {
+				grid[y][x] = marker
+			}
+		}
+	}
+
+	// Generate the ASCII map
+	var sb strings.Builder
+
+	// Header row with column numbers
+	sb.WriteString("   ")
+	for i := 1; i <= h.Size; i++ {
+		sb.WriteString(fmt.Sprintf("%d ", i))
+	}
+	sb.WriteString("\n")
+
+	// Heatmap rows
+	for i := 0; i < h.Size; i++ {
+		sb.WriteString(fmt.Sprintf("%c  ", 'A'+byte(i)))
+		for j := 0; j < h.Size; j++ {
+			sb.WriteString(grid[i][j] + " ")
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Status string `json:"status"`
 	Error  string `json:"error"`
 }
 
-// StrikeResponse represents a strike response
+// StrikeResponse represents a strike response. Result is HIT or MISS, or
+// HIT, DESTROYED (the last cell of a plant was hit) or MISS in partial damage
+// games.
 type StrikeResponse struct {
 	Status string `json:"status"`
 	Result string `json:"result"`
 }
 
+// ReplayMove represents a single strike in a game replay
+type ReplayMove struct {
+	Move       int    `json:"move"`
+	Attacker   string `json:"attacker"`
+	Target     string `json:"target"`
+	Coordinate string `json:"coordinate"`
+	Result     string `json:"result"`
+	Capacity   int    `json:"capacity"`
+}
+
+// ReplayStep represents the state of a game after a given number of moves
+type ReplayStep struct {
+	Step   int               `json:"step"`
+	Moves  int               `json:"moves"`
+	Size   int               `json:"size"`
+	Move   *ReplayMove       `json:"move"`
+	Status GameStatus        `json:"status"`
+	Turn   string            `json:"turn"`
+	Winner *string           `json:"winner"`
+	Boards map[string]*Board `json:"boards"`
+}
+
+// StrikeInfo represents a strike made against a player
+type StrikeInfo struct {
+	Attacker   string `json:"attacker"`
+	Coordinate string `json:"coordinate"`
+	Result     string `json:"result"`
+}
+
+// WaitResponse represents the response of the wait for turn long poll.
+// Status is YOUR_TURN, GAME_OVER or TIMEOUT.
+type WaitResponse struct {
+	Status     string      `json:"status"`
+	Game       *Game       `json:"game"`
+	LastStrike *StrikeInfo `json:"last_strike"`
+}
+
+// LobbyFilter selects the games listed in the lobby
+type LobbyFilter struct {
+	Status  GameStatus
+	Size    int
+	HasSeat bool
+	// Cursor is the cursor returned with the previous page
+	Cursor string
+	Limit  int
+}
+
+// LobbyGame represents a public game listed in the lobby
+type LobbyGame struct {
+	ID        string     `json:"id"`
+	Status    GameStatus `json:"status"`
+	Size      int        `json:"size"`
+	Capacity  int        `json:"capacity"`
+	Players   int        `json:"players"`
+	OpenSeats int        `json:"open_seats"`
+}
+
+// LobbyResponse represents a page of the lobby.
+// NextCursor is empty on the last page.
+type LobbyResponse struct {
+	Games      []LobbyGame `json:"games"`
+	NextCursor string      `json:"next_cursor"`
+}
+
+// QueueStatus represents the state of a player in the matchmaking queue.
+// Status is WAITING until the player is placed in a game, then MATCHED with
+// the game ID and the player token.
+type QueueStatus struct {
+	Ticket  string `json:"ticket"`
+	Status  string `json:"status"`
+	Player  string `json:"player"`
+	Size    int    `json:"size"`
+	Players int    `json:"players"`
+	Waiting int    `json:"waiting,omitempty"`
+	GameID  string `json:"game_id,omitempty"`
+	Token   string `json:"token,omitempty"`
+	// ExpiresAt is only set for signed tokens
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Matchmaking queue statuses. MATCHING is only seen on stored tickets, while
+// a server places the player in a game; players see it as WAITING.
+const (
+	QueueStatusWaiting  = "WAITING"
+	QueueStatusMatching = "MATCHING"
+	QueueStatusMatched  = "MATCHED"
+)
+
+// QueueTicket represents a player in the matchmaking queue. Tickets are
+// kept in the store so every server replica sees the same queue.
+type QueueTicket struct {
+	ID     string
+	Player string
+	// Account is set for registered players, whose API key was checked
+	// when they joined the queue
+	Account  bool
+	Size     int
+	Capacity int
+	Players  int
+	Status   string
+	GameID   string
+	Token    string
+	// QueuedAt orders the queue, LastSeen is the last time the player
+	// checked the ticket and UpdatedAt the last change of status
+	QueuedAt  time.Time
+	LastSeen  time.Time
+	UpdatedAt time.Time
+	Version   int64
+}
+
+// Account represents a registered player. Its rating is updated at the end
+// of every game it plays against other registered players.
+type Account struct {
+	Name      string    `json:"name"`
+	KeyHash   string    `json:"-"`
+	Rating    float64   `json:"rating"`
+	Games     int       `json:"games"`
+	Wins      int       `json:"wins"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int64     `json:"-"`
+}
+
+// RegisterResponse represents the response of registering an account.
+// The key is only returned once.
+type RegisterResponse struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// LeaderboardEntry represents an account in the leaderboard
+type LeaderboardEntry struct {
+	Rank int `json:"rank"`
+	Account
+}
+
+// SpectateResponse represents the response of joining a game as spectator.
+// ExpiresAt is only set for signed tokens.
+type SpectateResponse struct {
+	Token     string        `json:"token"`
+	ExpiresAt *time.Time    `json:"expires_at,omitempty"`
+	Mode      SpectatorMode `json:"mode"`
+	Delay     int           `json:"delay,omitempty"`
+}
+
+// SpectatorView represents a game as seen by its spectators. Moves is the
+// number of moves included, which is behind the game in delayed mode.
+type SpectatorView struct {
+	Mode  SpectatorMode `json:"mode"`
+	Delay int           `json:"delay,omitempty"`
+	Moves int           `json:"moves"`
+	Game  *Game         `json:"game"`
+}
+
 // ReadyResponse represents a ready response
 type ReadyResponse struct {
 	Result string `json:"result"`
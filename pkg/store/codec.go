@@ -0,0 +1,53 @@
+package store
+
+import (
+	"encoding/json"
+
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+// record is the serialized form of a game. It includes the fields that are
+// hidden from the API but still needed to restore the game.
+type record struct {
+	*models.Game
+	Size           int            `json:"size"`
+	Capacity       int            `json:"capacity"`
+	SpectatorToken string         `json:"spectator_token,omitempty"`
+	WeatherSeed    int64          `json:"weather_seed,omitempty"`
+	RoundTurns     int            `json:"round_turns,omitempty"`
+	Events         []models.Event `json:"events,omitempty"`
+}
+
+// encodeGame serializes a game for storage
+func encodeGame(game *models.Game) ([]byte, error) {
+	return json.Marshal(record{
+		Game:           game,
+		Size:           game.Size,
+		Capacity:       game.Capacity,
+		SpectatorToken: game.SpectatorToken,
+		WeatherSeed:    game.WeatherSeed,
+		RoundTurns:     game.RoundTurns,
+		Events:         game.Events,
+	})
+}
+
+// decodeGame restores a game serialized by encodeGame
+func decodeGame(data []byte) (*models.Game, error) {
+	rec := record{Game: &models.Game{}}
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+
+	game := rec.Game
+	game.Size = rec.Size
+	game.Capacity = rec.Capacity
+	game.SpectatorToken = rec.SpectatorToken
+	game.WeatherSeed = rec.WeatherSeed
+	game.RoundTurns = rec.RoundTurns
+	game.Events = rec.Events
+	if game.Players == nil {
+		game.Players = make(map[string]models.PlayerInfo)
+	}
+
+	return game, nil
+}
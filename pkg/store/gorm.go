@@ -0,0 +1,658 @@
+package store
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/xorduna/energywar/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gameRow is the database representation of a game. Besides the encoded
+// game it carries the fields games are looked up by, so the database can
+// select them without decoding every game. Times are kept in UTC, which
+// SQLite needs to compare the stored times.
+type gameRow struct {
+	ID           string `gorm:"primaryKey"`
+	Status       string `gorm:"index"`
+	Version      int64  `gorm:"not null;default:1"`
+	Data         []byte
+	Public       bool `gorm:"index"`
+	Size         int
+	Players      int
+	TurnDeadline *time.Time `gorm:"index"`
+	CreatedAt    time.Time  `gorm:"index"`
+	UpdatedAt    time.Time
+}
+
+// rowColumns are the columns of a game row derived from the game, which
+// were added after the first version of the table
+var rowColumns = []string{"Public", "Size", "Players", "TurnDeadline"}
+
+// newGameRow creates the row of a game
+func newGameRow(game *models.Game, version int64, data []byte) *gameRow {
+	return &gameRow{
+		ID:           game.ID,
+		Status:       string(game.Status),
+		Version:      version,
+		Data:         data,
+		Public:       game.Public,
+		Size:         game.Size,
+		Players:      len(game.Players),
+		TurnDeadline: turnDeadline(game),
+		CreatedAt:    createdAt(game),
+	}
+}
+
+// columns returns the columns of a game row derived from the game
+func columns(game *models.Game) map[string]interface{} {
+	return map[string]interface{}{
+		"status":        string(game.Status),
+		"public":        game.Public,
+		"size":          game.Size,
+		"players":       len(game.Players),
+		"turn_deadline": turnDeadline(game),
+	}
+}
+
+// turnDeadline returns the turn deadline of a game as stored in its row
+func turnDeadline(game *models.Game) *time.Time {
+	if game.TurnDeadline == nil {
+		return nil
+	}
+	deadline := game.TurnDeadline.UTC()
+
+	return &deadline
+}
+
+// TableName sets the table name for game rows
+func (gameRow) TableName() string {
+	return "games"
+}
+
+// accountRow is the database representation of an account
+type accountRow struct {
+	Name      string  `gorm:"primaryKey"`
+	KeyHash   string  `gorm:"not null"`
+	Rating    float64 `gorm:"index"`
+	Games     int
+	Wins      int
+	Version   int64 `gorm:"not null;default:1"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName sets the table name for account rows
+func (accountRow) TableName() string {
+	return "accounts"
+}
+
+// account converts the row to an account
+func (row accountRow) account() *models.Account {
+	return &models.Account{
+		Name:      row.Name,
+		KeyHash:   row.KeyHash,
+		Rating:    row.Rating,
+		Games:     row.Games,
+		Wins:      row.Wins,
+		CreatedAt: row.CreatedAt,
+		Version:   row.Version,
+	}
+}
+
+// ticketRow is the database representation of a matchmaking ticket.
+// Tickets are looked up by the game they queue for and their status, in
+// queue order.
+type ticketRow struct {
+	ID        string `gorm:"primaryKey"`
+	Player    string
+	Account   bool
+	Size      int       `gorm:"index:idx_tickets_queue"`
+	Capacity  int       `gorm:"index:idx_tickets_queue"`
+	Players   int       `gorm:"index:idx_tickets_queue"`
+	Status    string    `gorm:"index:idx_tickets_queue"`
+	QueuedAt  time.Time `gorm:"index:idx_tickets_queue"`
+	GameID    string
+	Token     string
+	LastSeen  time.Time
+	UpdatedAt time.Time
+	Version   int64 `gorm:"not null;default:1"`
+}
+
+// TableName sets the table name for ticket rows
+func (ticketRow) TableName() string {
+	return "tickets"
+}
+
+// newTicketRow creates the row of a ticket, with its times in UTC
+func newTicketRow(ticket *models.QueueTicket, version int64) *ticketRow {
+	return &ticketRow{
+		ID:        ticket.ID,
+		Player:    ticket.Player,
+		Account:   ticket.Account,
+		Size:      ticket.Size,
+		Capacity:  ticket.Capacity,
+		Players:   ticket.Players,
+		Status:    ticket.Status,
+		QueuedAt:  ticket.QueuedAt.UTC(),
+		GameID:    ticket.GameID,
+		Token:     ticket.Token,
+		LastSeen:  ticket.LastSeen.UTC(),
+		UpdatedAt: ticket.UpdatedAt.UTC(),
+		Version:   version,
+	}
+}
+
+// ticket converts the row to a ticket
+func (row ticketRow) ticket() *models.QueueTicket {
+	return &models.QueueTicket{
+		ID:        row.ID,
+		Player:    row.Player,
+		Account:   row.Account,
+		Size:      row.Size,
+		Capacity:  row.Capacity,
+		Players:   row.Players,
+		Status:    row.Status,
+		GameID:    row.GameID,
+		Token:     row.Token,
+		QueuedAt:  row.QueuedAt,
+		LastSeen:  row.LastSeen,
+		UpdatedAt: row.UpdatedAt,
+		Version:   row.Version,
+	}
+}
+
+// gormStore implements GameStore on top of a gorm database.
+// Updates are compare-and-swap on the version column, so several processes
+// can share the same database without a global lock.
+type gormStore struct {
+	db *gorm.DB
+	// serialize makes writes from this process run one at a time,
+	// which SQLite needs as it cannot run concurrent write transactions
+	serialize bool
+	mutex     sync.Mutex
+}
+
+// newGormStore migrates the schema and wraps the database
+func newGormStore(db *gorm.DB, serialize bool) (*gormStore, error) {
+	// Games stored before a column was added need it filled in
+	fill := false
+	if db.Migrator().HasTable(&gameRow{}) {
+		for _, column := range rowColumns {
+			if !db.Migrator().HasColumn(&gameRow{}, column) {
+				fill = true
+			}
+		}
+	}
+	if err := db.AutoMigrate(&gameRow{}, &accountRow{}, &ticketRow{}); err != nil {
+		return nil, err
+	}
+
+	s := &gormStore{db: db, serialize: serialize}
+	if fill {
+		if err := s.fillColumns(); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// fillColumns sets the columns derived from the game of every stored row
+func (s *gormStore) fillColumns() error {
+	var rows []gameRow
+	if err := s.db.Find(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		game, err := decodeRow(row)
+		if err != nil {
+			return err
+		}
+
+		updates := columns(game)
+		updates["created_at"] = createdAt(game)
+		if err := s.db.Model(&gameRow{}).Where("id = ?", row.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lock serializes writes when required by the database
+func (s *gormStore) lock() func() {
+	if !s.serialize {
+		return func() {}
+	}
+	s.mutex.Lock()
+	return s.mutex.Unlock
+}
+
+// Get retrieves a game by ID
+func (s *gormStore) Get(id string) (*models.Game, error) {
+	var row gameRow
+	if err := s.db.First(&row, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return decodeRow(row)
+}
+
+// decodeRow restores the game stored in a row
+func decodeRow(row gameRow) (*models.Game, error) {
+	game, err := decodeGame(row.Data)
+	if err != nil {
+		return nil, err
+	}
+	game.Version = row.Version
+
+	return game, nil
+}
+
+// Create inserts a new game
+func (s *gormStore) Create(game *models.Game) error {
+	defer s.lock()()
+
+	data, err := encodeGame(game)
+	if err != nil {
+		return err
+	}
+
+	// Insert only if the ID is free
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(newGameRow(game, 1, data))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrExists
+	}
+
+	return nil
+}
+
+// Put inserts or replaces a game
+func (s *gormStore) Put(game *models.Game) error {
+	defer s.lock()()
+
+	data, err := encodeGame(game)
+	if err != nil {
+		return err
+	}
+
+	version := game.Version
+	if version == 0 {
+		version = 1
+	}
+
+	return s.db.Save(newGameRow(game, version, data)).Error
+}
+
+// List returns all stored games ordered by ID
+func (s *gormStore) List() ([]*models.Game, error) {
+	var rows []gameRow
+	if err := s.db.Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	return decodeRows(rows)
+}
+
+// ListByStatus returns the stored games with the given status ordered by ID
+func (s *gormStore) ListByStatus(status models.GameStatus) ([]*models.Game, error) {
+	var rows []gameRow
+	if err := s.db.Where("status = ?", string(status)).Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	return decodeRows(rows)
+}
+
+// ListGames returns a page of the games matching the query, ordered by
+// creation time and ID, and the cursor of the next page (empty on the last
+// page)
+func (s *gormStore) ListGames(query GameQuery) ([]*models.Game, string, error) {
+	db := s.db.Order("created_at").Order("id").Limit(query.Limit + 1)
+	if query.Public {
+		db = db.Where("public = ?", true)
+	}
+	if query.Status != "" {
+		db = db.Where("status = ?", string(query.Status))
+	}
+	if query.Size != 0 {
+		db = db.Where("size = ?", query.Size)
+	}
+	if query.MaxPlayers != 0 {
+		db = db.Where("status = ? AND players < ?", string(models.GameStatusPending), query.MaxPlayers)
+	}
+	if query.Cursor != "" {
+		after, id, err := parseCursor(query.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		db = db.Where("created_at > ? OR (created_at = ? AND id > ?)", after, after, id)
+	}
+
+	var rows []gameRow
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, "", err
+	}
+	games, err := decodeRows(rows)
+	if err != nil {
+		return nil, "", err
+	}
+
+	games, next := page(games, query.Limit)
+
+	return games, next, nil
+}
+
+// ListExpired returns the games in progress whose turn deadline is not after
+// now, ordered by ID
+func (s *gormStore) ListExpired(now time.Time) ([]*models.Game, error) {
+	var rows []gameRow
+	err := s.db.Where("status = ? AND turn_deadline <= ?", string(models.GameStatusInProgress), now.UTC()).
+		Order("id").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeRows(rows)
+}
+
+// decodeRows decodes a list of rows into games
+func decodeRows(rows []gameRow) ([]*models.Game, error) {
+	games := make([]*models.Game, 0, len(rows))
+	for _, row := range rows {
+		game, err := decodeRow(row)
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, game)
+	}
+
+	return games, nil
+}
+
+// Delete removes a game
+func (s *gormStore) Delete(id string) error {
+	defer s.lock()()
+
+	result := s.db.Delete(&gameRow{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Update atomically applies fn to a game and stores the result.
+// The game is read without holding any lock and written back only if its
+// version did not change in between, so a concurrent update from another
+// process makes this one fail with ErrConflict instead of blocking.
+func (s *gormStore) Update(id string, fn UpdateFunc) (*models.Game, error) {
+	defer s.lock()()
+
+	var row gameRow
+	if err := s.db.First(&row, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	game, err := decodeRow(row)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(game); err != nil {
+		return nil, err
+	}
+
+	data, err := encodeGame(game)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only write if nobody else updated the game since we read it
+	updates := columns(game)
+	updates["version"] = row.Version + 1
+	updates["data"] = data
+	result := s.db.Model(&gameRow{}).
+		Where("id = ? AND version = ?", row.ID, row.Version).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrConflict
+	}
+	game.Version = row.Version + 1
+
+	return game, nil
+}
+
+// GetAccount retrieves an account by name
+func (s *gormStore) GetAccount(name string) (*models.Account, error) {
+	var row accountRow
+	if err := s.db.First(&row, "name = ?", name).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+
+	return row.account(), nil
+}
+
+// CreateAccount inserts a new account
+func (s *gormStore) CreateAccount(account *models.Account) error {
+	defer s.lock()()
+
+	// Insert only if the name is free, without relying on the error the
+	// driver returns for a duplicate key
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&accountRow{
+		Name:      account.Name,
+		KeyHash:   account.KeyHash,
+		Rating:    account.Rating,
+		Games:     account.Games,
+		Wins:      account.Wins,
+		Version:   1,
+		CreatedAt: account.CreatedAt,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAccountExists
+	}
+
+	return nil
+}
+
+// ListAccounts returns the accounts ordered by rating, highest first
+func (s *gormStore) ListAccounts(limit int) ([]*models.Account, error) {
+	query := s.db.Order("rating desc").Order("name")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var rows []accountRow
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	accounts := make([]*models.Account, 0, len(rows))
+	for _, row := range rows {
+		accounts = append(accounts, row.account())
+	}
+
+	return accounts, nil
+}
+
+// UpdateAccounts atomically applies fn to the existing accounts among names
+func (s *gormStore) UpdateAccounts(names []string, fn UpdateAccountsFunc) error {
+	defer s.lock()()
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var rows []accountRow
+		if err := tx.Where("name IN ?", names).Find(&rows).Error; err != nil {
+			return err
+		}
+
+		accounts := make(map[string]*models.Account)
+		for _, row := range rows {
+			accounts[row.Name] = row.account()
+		}
+		if err := fn(accounts); err != nil {
+			return err
+		}
+
+		// Only write if nobody else updated the accounts since we read them
+		for _, row := range rows {
+			account, exists := accounts[row.Name]
+			if !exists {
+				continue
+			}
+			result := tx.Model(&accountRow{}).
+				Where("name = ? AND version = ?", row.Name, row.Version).
+				Updates(map[string]interface{}{
+					"rating":  account.Rating,
+					"games":   account.Games,
+					"wins":    account.Wins,
+					"version": row.Version + 1,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrConflict
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetTicket retrieves a ticket by ID
+func (s *gormStore) GetTicket(id string) (*models.QueueTicket, error) {
+	var row ticketRow
+	if err := s.db.First(&row, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTicketNotFound
+		}
+		return nil, err
+	}
+
+	return row.ticket(), nil
+}
+
+// CreateTicket inserts a new ticket
+func (s *gormStore) CreateTicket(ticket *models.QueueTicket) error {
+	defer s.lock()()
+
+	return s.db.Create(newTicketRow(ticket, 1)).Error
+}
+
+// ListTickets returns the tickets matching the query in queue order
+func (s *gormStore) ListTickets(query TicketQuery) ([]*models.QueueTicket, error) {
+	db := s.db.Order("queued_at").Order("id")
+	if query.Size != 0 {
+		db = db.Where("size = ?", query.Size)
+	}
+	if query.Capacity != 0 {
+		db = db.Where("capacity = ?", query.Capacity)
+	}
+	if query.Players != 0 {
+		db = db.Where("players = ?", query.Players)
+	}
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+
+	var rows []ticketRow
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	tickets := make([]*models.QueueTicket, 0, len(rows))
+	for _, row := range rows {
+		tickets = append(tickets, row.ticket())
+	}
+
+	return tickets, nil
+}
+
+// UpdateTicket atomically applies fn to a ticket and stores the result.
+// Like Update, the write only goes through if the version did not change
+// since the ticket was read.
+func (s *gormStore) UpdateTicket(id string, fn UpdateTicketFunc) (*models.QueueTicket, error) {
+	defer s.lock()()
+
+	var row ticketRow
+	if err := s.db.First(&row, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTicketNotFound
+		}
+		return nil, err
+	}
+
+	ticket := row.ticket()
+	if err := fn(ticket); err != nil {
+		return nil, err
+	}
+
+	updated := newTicketRow(ticket, row.Version+1)
+	result := s.db.Model(&ticketRow{}).
+		Where("id = ? AND version = ?", row.ID, row.Version).
+		Updates(map[string]interface{}{
+			"status":     updated.Status,
+			"game_id":    updated.GameID,
+			"token":      updated.Token,
+			"last_seen":  updated.LastSeen,
+			"updated_at": updated.UpdatedAt,
+			"version":    updated.Version,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrConflict
+	}
+
+	return updated.ticket(), nil
+}
+
+// DeleteTicket removes a ticket
+func (s *gormStore) DeleteTicket(id string) error {
+	defer s.lock()()
+
+	result := s.db.Delete(&ticketRow{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTicketNotFound
+	}
+
+	return nil
+}
+
+// Close releases the database connection
+func (s *gormStore) Close() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.Close()
+}
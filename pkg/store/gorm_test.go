@@ -0,0 +1,163 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+func TestSQLiteConcurrentUpdates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "energywar.db")
+
+	// Two stores on the same file act as two server processes
+	first, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	testConcurrentUpdates(t, first, second)
+}
+
+func TestPostgresConcurrentUpdates(t *testing.T) {
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+
+	first, err := NewPostgresStore(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := NewPostgresStore(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	testConcurrentUpdates(t, first, second)
+}
+
+// testConcurrentUpdates races two updates of the same game, one through each
+// store. Both read the game before either writes it, so exactly one of them
+// must fail with ErrConflict and the game must only keep the other change.
+func testConcurrentUpdates(t *testing.T, first, second GameStore) {
+	id := fmt.Sprintf("race-%d", time.Now().UnixNano())
+	if err := first.Create(&models.Game{ID: id, Status: models.GameStatusPending}); err != nil {
+		t.Fatal(err)
+	}
+	defer first.Delete(id)
+
+	// Both updates wait until the other one read the game, then the first
+	// one writes while the second one waits for it to be done
+	var read sync.WaitGroup
+	read.Add(2)
+	firstDone := make(chan struct{})
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer close(firstDone)
+		_, errs[0] = first.Update(id, func(game *models.Game) error {
+			read.Done()
+			read.Wait()
+			game.Turn = "first"
+			return nil
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		_, errs[1] = second.Update(id, func(game *models.Game) error {
+			read.Done()
+			read.Wait()
+			<-firstDone
+			game.Turn = "second"
+			return nil
+		})
+	}()
+	wg.Wait()
+
+	if errs[0] != nil {
+		t.Fatalf("first update failed: %v", errs[0])
+	}
+	if !errors.Is(errs[1], ErrConflict) {
+		t.Fatalf("expected the second update to fail with ErrConflict, got %v", errs[1])
+	}
+
+	game, err := second.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.Turn != "first" || game.Version != 2 {
+		t.Fatalf("expected the first update at version 2, got %q at version %d", game.Turn, game.Version)
+	}
+}
+
+func TestSQLiteListExpired(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "energywar.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// Deadlines in another time zone must compare by instant
+	now := time.Now()
+	zone := time.FixedZone("UTC+5", 5*60*60)
+	past := now.Add(-time.Second).In(zone)
+	future := now.Add(time.Minute).In(zone)
+
+	games := []*models.Game{
+		{ID: "expired", Status: models.GameStatusInProgress, TurnDeadline: &past},
+		{ID: "running", Status: models.GameStatusInProgress, TurnDeadline: &future},
+		{ID: "untimed", Status: models.GameStatusInProgress},
+		{ID: "pending", Status: models.GameStatusPending},
+	}
+	for _, game := range games {
+		if err := s.Create(game); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The deadline follows the updates of the game
+	_, err = s.Update("running", func(game *models.Game) error {
+		game.TurnDeadline = &past
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Update("expired", func(game *models.Game) error {
+		game.Status = models.GameStatusEnd
+		game.TurnDeadline = nil
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired, err := s.ListExpired(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 1 || expired[0].ID != "running" {
+		ids := make([]string, 0, len(expired))
+		for _, game := range expired {
+			ids = append(ids, game.ID)
+		}
+		t.Fatalf("expected only running to be expired, got %v", ids)
+	}
+}
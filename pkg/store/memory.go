@@ -0,0 +1,358 @@
+package store
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+// MemoryStore keeps games, accounts and the matchmaking queue in memory.
+// They are lost when the process exits.
+type MemoryStore struct {
+	games    map[string]*models.Game
+	accounts map[string]models.Account
+	tickets  map[string]models.QueueTicket
+	mutex    sync.RWMutex
+}
+
+// NewMemoryStore creates a new in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		games:    make(map[string]*models.Game),
+		accounts: make(map[string]models.Account),
+		tickets:  make(map[string]models.QueueTicket),
+	}
+}
+
+// Get retrieves a game by ID
+func (s *MemoryStore) Get(id string) (*models.Game, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	game, exists := s.games[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	return game.Clone(), nil
+}
+
+// Create inserts a new game
+func (s *MemoryStore) Create(game *models.Game) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.games[game.ID]; exists {
+		return ErrExists
+	}
+	stored := game.Clone()
+	stored.Version = 1
+	s.games[game.ID] = stored
+
+	return nil
+}
+
+// Put inserts or replaces a game
+func (s *MemoryStore) Put(game *models.Game) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored := game.Clone()
+	if stored.Version == 0 {
+		stored.Version = 1
+	}
+	s.games[game.ID] = stored
+
+	return nil
+}
+
+// List returns all stored games ordered by ID
+func (s *MemoryStore) List() ([]*models.Game, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	games := make([]*models.Game, 0, len(s.games))
+	for _, game := range s.games {
+		games = append(games, game.Clone())
+	}
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].ID < games[j].ID
+	})
+
+	return games, nil
+}
+
+// ListByStatus returns the stored games with the given status ordered by ID
+func (s *MemoryStore) ListByStatus(status models.GameStatus) ([]*models.Game, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	games := make([]*models.Game, 0)
+	for _, game := range s.games {
+		if game.Status == status {
+			games = append(games, game.Clone())
+		}
+	}
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].ID < games[j].ID
+	})
+
+	return games, nil
+}
+
+// ListGames returns a page of the games matching the query, ordered by
+// creation time and ID, and the cursor of the next page (empty on the last
+// page)
+func (s *MemoryStore) ListGames(query GameQuery) ([]*models.Game, string, error) {
+	var after time.Time
+	var afterID string
+	if query.Cursor != "" {
+		var err error
+		after, afterID, err = parseCursor(query.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	games := make([]*models.Game, 0)
+	for _, game := range s.games {
+		if !query.matches(game) {
+			continue
+		}
+		created := createdAt(game)
+		if query.Cursor != "" && (created.Before(after) || (created.Equal(after) && game.ID <= afterID)) {
+			continue
+		}
+		games = append(games, game)
+	}
+	sort.Slice(games, func(i, j int) bool {
+		ci, cj := createdAt(games[i]), createdAt(games[j])
+		if !ci.Equal(cj) {
+			return ci.Before(cj)
+		}
+		return games[i].ID < games[j].ID
+	})
+
+	games, next := page(games, query.Limit)
+	for i, game := range games {
+		games[i] = game.Clone()
+	}
+
+	return games, next, nil
+}
+
+// ListExpired returns the games in progress whose turn deadline is not after
+// now, ordered by ID
+func (s *MemoryStore) ListExpired(now time.Time) ([]*models.Game, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	games := make([]*models.Game, 0)
+	for _, game := range s.games {
+		if game.Status == models.GameStatusInProgress &&
+			game.TurnDeadline != nil && !now.Before(*game.TurnDeadline) {
+			games = append(games, game.Clone())
+		}
+	}
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].ID < games[j].ID
+	})
+
+	return games, nil
+}
+
+// Delete removes a game
+func (s *MemoryStore) Delete(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.games[id]; !exists {
+		return ErrNotFound
+	}
+	delete(s.games, id)
+
+	return nil
+}
+
+// Update atomically applies fn to a game and stores the result
+func (s *MemoryStore) Update(id string, fn UpdateFunc) (*models.Game, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	game, exists := s.games[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	// Work on a copy so a failed update leaves the stored game untouched
+	updated := game.Clone()
+	if err := fn(updated); err != nil {
+		return nil, err
+	}
+	updated.Version = game.Version + 1
+	s.games[id] = updated
+
+	return updated.Clone(), nil
+}
+
+// GetAccount retrieves an account by name
+func (s *MemoryStore) GetAccount(name string) (*models.Account, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	account, exists := s.accounts[name]
+	if !exists {
+		return nil, ErrAccountNotFound
+	}
+
+	return &account, nil
+}
+
+// CreateAccount inserts a new account
+func (s *MemoryStore) CreateAccount(account *models.Account) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.accounts[account.Name]; exists {
+		return ErrAccountExists
+	}
+	stored := *account
+	stored.Version = 1
+	s.accounts[account.Name] = stored
+
+	return nil
+}
+
+// ListAccounts returns the accounts ordered by rating, highest first
+func (s *MemoryStore) ListAccounts(limit int) ([]*models.Account, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	accounts := make([]*models.Account, 0, len(s.accounts))
+	for _, account := range s.accounts {
+		account := account
+		accounts = append(accounts, &account)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].Rating != accounts[j].Rating {
+			return accounts[i].Rating > accounts[j].Rating
+		}
+		return accounts[i].Name < accounts[j].Name
+	})
+	if limit > 0 && len(accounts) > limit {
+		accounts = accounts[:limit]
+	}
+
+	return accounts, nil
+}
+
+// UpdateAccounts atomically applies fn to the existing accounts among names
+func (s *MemoryStore) UpdateAccounts(names []string, fn UpdateAccountsFunc) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Work on copies so a failed update leaves the stored accounts untouched
+	accounts := make(map[string]*models.Account)
+	for _, name := range names {
+		if account, exists := s.accounts[name]; exists {
+			accounts[name] = &account
+		}
+	}
+	if err := fn(accounts); err != nil {
+		return err
+	}
+	for name, account := range accounts {
+		account.Version++
+		s.accounts[name] = *account
+	}
+
+	return nil
+}
+
+// GetTicket retrieves a ticket by ID
+func (s *MemoryStore) GetTicket(id string) (*models.QueueTicket, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	ticket, exists := s.tickets[id]
+	if !exists {
+		return nil, ErrTicketNotFound
+	}
+
+	return &ticket, nil
+}
+
+// CreateTicket inserts a new ticket
+func (s *MemoryStore) CreateTicket(ticket *models.QueueTicket) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored := *ticket
+	stored.Version = 1
+	s.tickets[ticket.ID] = stored
+
+	return nil
+}
+
+// ListTickets returns the tickets matching the query in queue order
+func (s *MemoryStore) ListTickets(query TicketQuery) ([]*models.QueueTicket, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	tickets := make([]*models.QueueTicket, 0)
+	for _, ticket := range s.tickets {
+		if query.matches(&ticket) {
+			ticket := ticket
+			tickets = append(tickets, &ticket)
+		}
+	}
+	sort.Slice(tickets, func(i, j int) bool {
+		if !tickets[i].QueuedAt.Equal(tickets[j].QueuedAt) {
+			return tickets[i].QueuedAt.Before(tickets[j].QueuedAt)
+		}
+		return tickets[i].ID < tickets[j].ID
+	})
+
+	return tickets, nil
+}
+
+// UpdateTicket atomically applies fn to a ticket and stores the result
+func (s *MemoryStore) UpdateTicket(id string, fn UpdateTicketFunc) (*models.QueueTicket, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ticket, exists := s.tickets[id]
+	if !exists {
+		return nil, ErrTicketNotFound
+	}
+	if err := fn(&ticket); err != nil {
+		return nil, err
+	}
+	ticket.Version++
+	s.tickets[id] = ticket
+
+	return &ticket, nil
+}
+
+// DeleteTicket removes a ticket
+func (s *MemoryStore) DeleteTicket(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.tickets[id]; !exists {
+		return ErrTicketNotFound
+	}
+	delete(s.tickets, id)
+
+	return nil
+}
+
+// Close releases any resources held by the store
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,32 @@
+package store
+
+import (
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// PostgresStore persists games in a PostgreSQL database.
+// Several server replicas can share the same database: concurrent updates to
+// the same game are detected through the version column and rejected with
+// ErrConflict.
+type PostgresStore struct {
+	*gormStore
+}
+
+// NewPostgresStore connects to the PostgreSQL database described by dsn
+func NewPostgresStore(dsn string) (*PostgresStore, error) {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Warn),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := newGormStore(db, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PostgresStore{gormStore: s}, nil
+}
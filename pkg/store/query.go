@@ -0,0 +1,90 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+// createdAt returns when a game was created, the time of its first event.
+// It is rounded to microseconds, the precision of PostgreSQL, so cursors
+// match the stored times exactly.
+func createdAt(game *models.Game) time.Time {
+	if len(game.Events) == 0 {
+		return time.Time{}
+	}
+
+	return game.Events[0].Time.UTC().Truncate(time.Microsecond)
+}
+
+// gameCursor returns the cursor of the page after a game
+func gameCursor(game *models.Game) string {
+	return fmt.Sprintf("%d-%s", createdAt(game).UnixMicro(), game.ID)
+}
+
+// parseCursor returns the creation time and the ID of the game a cursor
+// points to. Game IDs have no dashes, so the time is before the last one;
+// it is negative for games created before 1970.
+func parseCursor(cursor string) (time.Time, string, error) {
+	i := strings.LastIndex(cursor, "-")
+	if i < 0 || i == len(cursor)-1 {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	micros, id := cursor[:i], cursor[i+1:]
+	n, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	return time.UnixMicro(n).UTC(), id, nil
+}
+
+// page cuts a list of games ordered by creation time and ID to limit games,
+// and returns the cursor of the next page if there were more games
+func page(games []*models.Game, limit int) ([]*models.Game, string) {
+	if len(games) <= limit {
+		return games, ""
+	}
+	games = games[:limit]
+
+	return games, gameCursor(games[limit-1])
+}
+
+// matches reports whether a game is selected by a query, ignoring the cursor
+func (q GameQuery) matches(game *models.Game) bool {
+	if q.Public && !game.Public {
+		return false
+	}
+	if q.Status != "" && game.Status != q.Status {
+		return false
+	}
+	if q.Size != 0 && game.Size != q.Size {
+		return false
+	}
+	if q.MaxPlayers != 0 && (game.Status != models.GameStatusPending || len(game.Players) >= q.MaxPlayers) {
+		return false
+	}
+
+	return true
+}
+
+// matches reports whether a ticket is selected by a query
+func (q TicketQuery) matches(ticket *models.QueueTicket) bool {
+	if q.Size != 0 && ticket.Size != q.Size {
+		return false
+	}
+	if q.Capacity != 0 && ticket.Capacity != q.Capacity {
+		return false
+	}
+	if q.Players != 0 && ticket.Players != q.Players {
+		return false
+	}
+	if q.Status != "" && ticket.Status != q.Status {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,160 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+func TestMemoryListGames(t *testing.T) {
+	testListGames(t, NewMemoryStore())
+}
+
+func TestSQLiteListGames(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "energywar.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	testListGames(t, s)
+}
+
+// testListGames pages through the games of a store with and without filters
+func testListGames(t *testing.T, s GameStore) {
+	created := time.Date(2025, 1, 1, 12, 0, 0, 123456789, time.FixedZone("UTC+2", 2*60*60))
+	players := func(n int) map[string]models.PlayerInfo {
+		players := make(map[string]models.PlayerInfo)
+		for i := 0; i < n; i++ {
+			players[fmt.Sprintf("player%d", i)] = models.PlayerInfo{}
+		}
+		return players
+	}
+	games := []struct {
+		id      string
+		offset  time.Duration
+		status  models.GameStatus
+		public  bool
+		size    int
+		players int
+	}{
+		// Created at the same time, ordered by ID
+		{"b", 0, models.GameStatusPending, true, 10, 1},
+		{"a", 0, models.GameStatusPending, true, 10, 4},
+		{"z", -time.Second, models.GameStatusInProgress, true, 10, 2},
+		{"c", time.Second, models.GameStatusPending, false, 10, 1},
+		{"d", 2 * time.Second, models.GameStatusPending, true, 8, 2},
+		{"e", 3 * time.Second, models.GameStatusEnd, true, 10, 2},
+	}
+	for _, g := range games {
+		err := s.Create(&models.Game{
+			ID:      g.id,
+			Status:  g.status,
+			Public:  g.public,
+			Size:    g.size,
+			Players: players(g.players),
+			Events: []models.Event{{
+				Seq:  1,
+				Type: models.EventGameCreated,
+				Time: created.Add(g.offset),
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query GameQuery
+		want  []string
+	}{
+		{"public", GameQuery{Public: true}, []string{"z", "a", "b", "d", "e"}},
+		{"all", GameQuery{}, []string{"z", "a", "b", "c", "d", "e"}},
+		{"status", GameQuery{Public: true, Status: models.GameStatusPending}, []string{"a", "b", "d"}},
+		{"size", GameQuery{Public: true, Size: 8}, []string{"d"}},
+		{"open seats", GameQuery{Public: true, MaxPlayers: 4}, []string{"b", "d"}},
+	}
+	for _, test := range tests {
+		for _, limit := range []int{1, 2, 10} {
+			query := test.query
+			query.Limit = limit
+
+			var ids []string
+			for pages := 0; ; pages++ {
+				if pages > len(games) {
+					t.Fatalf("%s with limit %d: too many pages", test.name, limit)
+				}
+				page, next, err := s.ListGames(query)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(page) > limit {
+					t.Fatalf("%s with limit %d: got a page of %d games", test.name, limit, len(page))
+				}
+				for _, game := range page {
+					ids = append(ids, game.ID)
+				}
+				if next == "" {
+					break
+				}
+				query.Cursor = next
+			}
+
+			if fmt.Sprint(ids) != fmt.Sprint(test.want) {
+				t.Fatalf("%s with limit %d: expected %v, got %v", test.name, limit, test.want, ids)
+			}
+		}
+	}
+
+	// Players joining are seen by the query
+	_, err := s.Update("b", func(game *models.Game) error {
+		game.Players = players(4)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, _, err := s.ListGames(GameQuery{Public: true, MaxPlayers: 4, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 1 || page[0].ID != "d" {
+		t.Fatalf("expected only d to have open seats, got %d games", len(page))
+	}
+
+	if _, _, err := s.ListGames(GameQuery{Cursor: "a", Limit: 10}); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	times := []time.Time{
+		{},
+		time.Date(1969, 7, 20, 20, 17, 0, 0, time.UTC),
+		time.Unix(0, 0).UTC(),
+		time.Date(2025, 1, 1, 12, 0, 0, 123456000, time.UTC),
+	}
+	for _, created := range times {
+		game := &models.Game{ID: "abc123", Events: []models.Event{{Time: created}}}
+		cursor := gameCursor(game)
+
+		at, id, err := parseCursor(cursor)
+		if err != nil {
+			t.Fatalf("cursor %q: %v", cursor, err)
+		}
+		if !at.Equal(created) || id != game.ID {
+			t.Fatalf("cursor %q gave %v %q, expected %v %q", cursor, at, id, created, game.ID)
+		}
+	}
+
+	for _, cursor := range []string{"", "a", "123-", "-abc", "x-abc"} {
+		if _, _, err := parseCursor(cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("cursor %q: expected ErrInvalidCursor, got %v", cursor, err)
+		}
+	}
+}
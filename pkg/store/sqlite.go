@@ -0,0 +1,29 @@
+package store
+
+import (
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// SQLiteStore persists games in a SQLite database file
+type SQLiteStore struct {
+	*gormStore
+}
+
+// NewSQLiteStore opens (or creates) the SQLite database at path
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Warn),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := newGormStore(db, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SQLiteStore{gormStore: s}, nil
+}
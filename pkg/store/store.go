@@ -0,0 +1,133 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/xorduna/energywar/pkg/models"
+)
+
+// ErrNotFound is returned when a game does not exist in the store
+var ErrNotFound = errors.New("game not found")
+
+// ErrExists is returned by Create when a game with the same ID already exists
+var ErrExists = errors.New("game already exists")
+
+// ErrConflict is returned by Update when the game was modified concurrently
+// (e.g. by another server replica). The operation can be safely retried.
+var ErrConflict = errors.New("CONFLICT")
+
+// ErrAccountNotFound is returned when an account does not exist in the store
+var ErrAccountNotFound = errors.New("account not found")
+
+// ErrAccountExists is returned when creating an account whose name is taken
+var ErrAccountExists = errors.New("ACCOUNT_EXISTS")
+
+// ErrTicketNotFound is returned when a matchmaking ticket does not exist in
+// the store
+var ErrTicketNotFound = errors.New("TICKET_NOT_FOUND")
+
+// ErrInvalidCursor is returned by ListGames when the cursor was not returned
+// by a previous call
+var ErrInvalidCursor = errors.New("INVALID_CURSOR")
+
+// GameQuery selects the games listed by ListGames
+type GameQuery struct {
+	// Public only lists the public games
+	Public bool
+	// Status and Size only list the games with the given status and board
+	// size, when set
+	Status models.GameStatus
+	Size   int
+	// MaxPlayers only lists the pending games with fewer players, when set
+	MaxPlayers int
+	// Cursor is the cursor returned with the previous page
+	Cursor string
+	// Limit is the number of games per page, it must be positive
+	Limit int
+}
+
+// TicketQuery selects the tickets listed by ListTickets. Zero fields select
+// every ticket.
+type TicketQuery struct {
+	// Size, Capacity and Players select the tickets queued for games with
+	// these parameters
+	Size     int
+	Capacity int
+	Players  int
+	Status   string
+}
+
+// UpdateFunc modifies a game inside a store transaction.
+// Returning an error aborts the update and leaves the stored game untouched.
+type UpdateFunc func(game *models.Game) error
+
+// UpdateAccountsFunc modifies a set of accounts, indexed by name, inside a
+// store transaction. Returning an error aborts the update.
+type UpdateAccountsFunc func(accounts map[string]*models.Account) error
+
+// UpdateTicketFunc modifies a ticket inside a store transaction.
+// Returning an error aborts the update.
+type UpdateTicketFunc func(ticket *models.QueueTicket) error
+
+// TicketStore persists the matchmaking queue
+type TicketStore interface {
+	// GetTicket retrieves a ticket by ID
+	GetTicket(id string) (*models.QueueTicket, error)
+	// CreateTicket inserts a new ticket
+	CreateTicket(ticket *models.QueueTicket) error
+	// ListTickets returns the tickets matching the query in queue order
+	ListTickets(query TicketQuery) ([]*models.QueueTicket, error)
+	// UpdateTicket atomically applies fn to a ticket and stores the
+	// result, failing with ErrConflict if the ticket was modified
+	// concurrently
+	UpdateTicket(id string, fn UpdateTicketFunc) (*models.QueueTicket, error)
+	// DeleteTicket removes a ticket
+	DeleteTicket(id string) error
+}
+
+// AccountStore persists registered player accounts
+type AccountStore interface {
+	// GetAccount retrieves an account by name
+	GetAccount(name string) (*models.Account, error)
+	// CreateAccount inserts a new account, failing with ErrAccountExists
+	// if the name is taken
+	CreateAccount(account *models.Account) error
+	// ListAccounts returns the accounts ordered by rating, highest first
+	ListAccounts(limit int) ([]*models.Account, error)
+	// UpdateAccounts atomically applies fn to the existing accounts among
+	// names and stores the result
+	UpdateAccounts(names []string, fn UpdateAccountsFunc) error
+}
+
+// GameStore persists games. Implementations always hand out copies, so a game
+// returned by Get or List can be read or modified without affecting the store.
+type GameStore interface {
+	AccountStore
+	TicketStore
+
+	// Get retrieves a game by ID
+	Get(id string) (*models.Game, error)
+	// Create inserts a new game, failing with ErrExists if the ID is taken
+	Create(game *models.Game) error
+	// Put inserts or replaces a game
+	Put(game *models.Game) error
+	// List returns all stored games
+	List() ([]*models.Game, error)
+	// ListByStatus returns the stored games with the given status
+	ListByStatus(status models.GameStatus) ([]*models.Game, error)
+	// ListGames returns a page of the games matching the query, ordered
+	// by creation time and ID, and the cursor of the next page (empty on
+	// the last page)
+	ListGames(query GameQuery) ([]*models.Game, string, error)
+	// ListExpired returns the games in progress whose turn deadline is not
+	// after now
+	ListExpired(now time.Time) ([]*models.Game, error)
+	// Delete removes a game
+	Delete(id string) error
+	// Update atomically applies fn to a game and stores the result,
+	// incrementing its version
+	Update(id string, fn UpdateFunc) (*models.Game, error)
+	// Close releases any resources held by the store
+	Close() error
+}